@@ -11,6 +11,21 @@ type CrudParams struct {
 	ExcludeTableNameFromMethods bool          `yaml:"exclude_table_name_from_methods"`
 	Default                     DefaultParams `yaml:"default"`
 	Tables                      Table         `yaml:"tables"`
+
+	// Use sqlc.arg('column') named params instead of positional params
+	NamedParams bool `yaml:"named_params"`
+
+	// Singular and plural forms of words which are not handled by inflection rules: person: people
+	IrregularWords map[string]string `yaml:"irregular_words"`
+	// Go template of method names with .Method, .Table, .Singular and .Plural fields.
+	// Example: {{.Method}}{{.Singular}}
+	MethodNameTemplate string `yaml:"method_name_template"`
+
+	// Files of generated queries: table (default), method or schema
+	Layout string `yaml:"layout"`
+	// Go template of file names with .Schema, .Table and .Method fields.
+	// Default is {{.Table}}_gen.sql
+	FileNameTemplate string `yaml:"file_name_template"`
 }
 
 type DefaultParams struct {
@@ -20,9 +35,41 @@ type DefaultParams struct {
 type Table map[string]TableParams
 
 type TableParams struct {
+	// Tables excluded from the pattern table key: `*`, `user_*` or `/^user_.*$/`
+	Exclude []string `yaml:"exclude"`
+	// Default is primary key columns from the schema
 	PrimaryColumn string                `yaml:"primary_column"`
 	OutputDir     string                `yaml:"output_dir"`
 	Methods       map[MethodType]Method `yaml:"methods"`
+	// Generate get method for each unique key and unique index
+	GetByUnique bool `yaml:"get_by_unique"`
+	// Methods for each foreign key of the table: find_by, total_by, delete_by
+	Relations map[MethodType]Method `yaml:"relations"`
+	// Delete method marks rows as deleted instead of deleting them
+	SoftDelete SoftDeleteParams `yaml:"soft_delete"`
+	// Column for optimistic locking. Update method increments and checks it
+	VersionColumn string `yaml:"version_column"`
+	// Comment of generated methods of the table. Default is the table comment from the schema
+	Description string `yaml:"description"`
+	// Update methods for the part of columns by primary key: UpdateUserStatus.
+	// Default update columns is the key
+	UpdateColumns map[string]Method `yaml:"update_columns"`
+	// Methods of the link table of many-to-many relation.
+	// Example for book_tags: AttachTagToBook, DetachTagFromBook, FindTagsByBook, FindBooksByTag, DeleteBookTags
+	ManyToMany ManyToManyParams `yaml:"many_to_many"`
+}
+
+type SoftDeleteParams struct {
+	Column string `yaml:"column"`
+	// Default is now(). For sqlite CURRENT_TIMESTAMP
+	Value string `yaml:"value"`
+}
+
+type ManyToManyParams struct {
+	// Foreign key column of the owner table: book_id
+	Column string `yaml:"column"`
+	// Foreign key column of the related table: tag_id
+	RelatedColumn string `yaml:"related_column"`
 }
 
 type Method struct {
@@ -33,9 +80,44 @@ type Method struct {
 	SkipColumns     []string                   `yaml:"skip_columns"`
 	ColumnValues    map[string]string          `yaml:"column_values"`
 
+	// Comment of the generated method. Default is the table description
+	Description string `yaml:"description"`
+
 	// For find method
 	Limit bool       `yaml:"limit"`
 	Order OrderParam `yaml:"order"`
+	// offset (default) or keyset.
+	// Keyset pagination uses order column and primary key as a cursor
+	Pagination string `yaml:"pagination"`
+
+	// For upsert method.
+	// Default conflict columns are primary key columns.
+	// Default update columns are all inserted columns except conflict columns.
+	// For patch method update columns limit patched columns.
+	// For increment method update columns are incremented
+	ConflictColumns []string `yaml:"conflict_columns"`
+	UpdateColumns   []string `yaml:"update_columns"`
+
+	// For increment method. Condition for the new value of each column: >= 0
+	Guard string `yaml:"guard"`
+
+	// For get and find methods. Explicit columns instead of SELECT *
+	SelectColumns  []string `yaml:"select_columns"`
+	ExcludeColumns []string `yaml:"exclude_columns"`
+
+	// For get and find methods. Row locking: FOR UPDATE SKIP LOCKED
+	Lock LockParams `yaml:"lock"`
+
+	// For tree method. Column which references the parent row: parent_id.
+	// Default is the foreign key column which references the same table
+	ParentColumn string `yaml:"parent_column"`
+}
+
+type LockParams struct {
+	ForUpdate  bool `yaml:"for_update"`
+	ForShare   bool `yaml:"for_share"`
+	SkipLocked bool `yaml:"skip_locked"`
+	NoWait     bool `yaml:"nowait"`
 }
 
 type OrderParam struct {
@@ -45,8 +127,11 @@ type OrderParam struct {
 
 type WhereParamsItem struct {
 	Value string `yaml:"value"`
-	// Default is = (equal)
+	// Default is = (equal).
+	// Also available: eq, ne, gt, gte, lt, lte, like, ilike, in, between
 	Operator string `yaml:"operator"`
+	// Condition is skipped if null value is passed
+	Optional bool `yaml:"optional"`
 }
 
 func (s *Method) AddWhereParam(key string, params WhereParamsItem) {
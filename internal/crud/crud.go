@@ -2,11 +2,17 @@ package crud
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/gobeam/stringy"
@@ -16,6 +22,11 @@ import (
 	"github.com/tkcrm/pgxgen/pkg/logger"
 	"github.com/tkcrm/pgxgen/pkg/sqlc"
 	"github.com/tkcrm/pgxgen/pkg/sqlc/cmd"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/compiler"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/inflection"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/multierr"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/opts"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/catalog"
 	"github.com/tkcrm/pgxgen/utils"
 )
 
@@ -96,17 +107,33 @@ func (s *crud) Generate(_ context.Context, args []string) error {
 		}
 
 		// get sql code for each tables
-		sqlData, err := s.generateSQLForEachTable(cfg.CrudParams, params)
+		sqlData, tablesParams, err := s.generateSQLForEachTable(cfg.CrudParams, params)
 		if err != nil {
 			return fmt.Errorf("generate sql for each tables error: %w", err)
 		}
 
+		defaultSchema := ""
+		if len(outputPaths) > 0 {
+			defaultSchema = s.catalogs[outputPaths[0]].Catalog.DefaultSchema
+		}
+
+		// files are prepared before removing of generated files, so invalid params do not remove them
+		files, err := s.getFiles(cfg.CrudParams, sqlData, tablesParams, queriesPaths, defaultSchema)
+		if err != nil {
+			return fmt.Errorf("getFiles error: %w", err)
+		}
+
 		// remove generated files
 		if cfg.CrudParams.AutoRemoveGeneratedFiles {
 			for _, p := range queriesPaths {
 				if err := utils.RemoveFiles(p, "_gen.sql"); err != nil {
 					return fmt.Errorf("remove sql generated files error: %w", err)
 				}
+
+				// files with custom names are found by the footer
+				if err := utils.RemoveGeneratedFiles(p, ".sql", generatedMarker); err != nil {
+					return fmt.Errorf("remove sql generated files error: %w", err)
+				}
 			}
 
 			for _, p := range s.config.Sqlc.GetPaths().OutPaths {
@@ -121,33 +148,9 @@ func (s *crud) Generate(_ context.Context, args []string) error {
 		}
 
 		// save new files
-		tableNamePaths := make(map[string]string, len(sqlData))
-		for tableName, data := range sqlData {
-			tableParams, ok := cfg.CrudParams.Tables[tableName]
-			if !ok {
-				return fmt.Errorf("can not find table params for table: %s", tableName)
-			}
-
-			if len(data) == 0 {
-				continue
-			}
-
-			if tableParams.OutputDir != "" {
-				tableNamePaths[tableParams.OutputDir] = tableName
-
-				if err := s.saveFile(cfg, data, tableName, tableParams.OutputDir); err != nil {
-					return fmt.Errorf("saveFile error: %w", err)
-				}
-
-				continue
-			}
-
-			for _, p := range queriesPaths {
-				tableNamePaths[p] = tableName
-
-				if err := s.saveFile(cfg, data, tableName, p); err != nil {
-					return fmt.Errorf("saveFile error: %w", err)
-				}
+		for filePath, data := range files {
+			if err := s.saveFile(filePath, data); err != nil {
+				return fmt.Errorf("saveFile error: %w", err)
 			}
 		}
 
@@ -163,36 +166,54 @@ type generateSQLForEachTableParams struct {
 }
 
 // generateSQLForEachTable - generate sql queries for each tables
-func (s *crud) generateSQLForEachTable(crudParams config.CrudParams, params []generateSQLForEachTableParams) (map[string][]byte, error) {
-	result := make(map[string][]byte, len(params))
+func (s *crud) generateSQLForEachTable(crudParams config.CrudParams, params []generateSQLForEachTableParams) (map[string][]generatedQueries, config.Table, error) {
+	result := make(map[string][]generatedQueries, len(params))
+	resultTables := make(config.Table)
+
+	// errors of generated queries. All queries are checked before the error is returned
+	var queriesErrs []error
+
+	if err := checkMethodNameTemplate(crudParams); err != nil {
+		return nil, nil, fmt.Errorf("method_name_template error: %w", err)
+	}
 
 	for _, param := range params {
+		queriesCompiler := s.catalogs[param.outputPath].Compiler
+
 		// Get all tables from postgres
 		tablesData, err := s.getTableMeta(param.outputPath)
 		if err != nil {
-			return nil, fmt.Errorf("getTableMeta error: %w", err)
+			return nil, nil, fmt.Errorf("getTableMeta error: %w", err)
 		}
 
 		if !engineType(param.engine).Valid() {
-			return nil, fmt.Errorf("invalid engine type: %s", param.engine)
+			return nil, nil, fmt.Errorf("invalid engine type: %s", param.engine)
+		}
+
+		var defaultSchema string
+		if item := s.catalogs[param.outputPath]; item.Catalog != nil {
+			defaultSchema = item.Catalog.DefaultSchema
 		}
 
-		// headText := fmt.Sprintf("-- Code generated by pgxgen. DO NOT EDIT.\n-- versions:\n--   pgxgen %s\n\n", s.config.Pgxgen.Version)
-		// builder.WriteString(headText)
+		tablesParams, err := resolveTables(crudParams, tablesData, defaultSchema)
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolveTables error: %w", err)
+		}
 
 		// Sort tables
-		tableKeys := make([]string, 0, len(crudParams.Tables))
-		for k := range crudParams.Tables {
+		tableKeys := make([]string, 0, len(tablesParams))
+		for k, v := range tablesParams {
 			tableKeys = append(tableKeys, k)
+			resultTables[k] = v
 		}
 		sort.Strings(tableKeys)
 
 		for _, tableName := range tableKeys {
-			tableParams := crudParams.Tables[tableName]
+			tableParams := tablesParams[tableName]
 
 			metaData := tablesData.getTableMetaData(tableName)
 			if metaData == nil {
-				return nil, fmt.Errorf("database does not exist table: %s", tableName)
+				return nil, nil, fmt.Errorf("database does not exist table: %s", tableName)
 			}
 
 			// Sort methods
@@ -203,25 +224,48 @@ func (s *crud) generateSQLForEachTable(crudParams config.CrudParams, params []ge
 			sort.Strings(methodKeys)
 
 			builder := new(strings.Builder)
+			var queries []generatedQueries
 
 			for _, methodType := range methodKeys {
 				methodParams := tableParams.Methods[config.MethodType(methodType)]
+				start := builder.Len()
 
 				params := processParams{
-					builder,
-					tableName,
-					*metaData,
-					methodParams,
-					tableParams,
-					engineType(param.engine),
+					builder:      builder,
+					table:        tableName,
+					metaData:     *metaData,
+					methodParams: methodParams,
+					tableParams:  tableParams,
+					engine:       engineType(param.engine),
+					namedParams:  crudParams.NamedParams,
 				}
 
 				var err error
 				switch config.MethodType(methodType) {
 				case METHOD_CREATE:
 					err = s.processCreate(crudParams, params)
+				case METHOD_UPSERT:
+					err = s.processUpsert(crudParams, params)
+				case METHOD_CREATE_BULK:
+					err = s.processCreateBulk(crudParams, params)
+				case METHOD_BATCH_GET, METHOD_BATCH_UPDATE, METHOD_BATCH_DELETE:
+					err = s.processBatch(crudParams, config.MethodType(methodType), params)
+				case METHOD_GET_MANY:
+					err = s.processGetMany(crudParams, params)
+				case METHOD_DELETE_MANY:
+					err = s.processDeleteMany(crudParams, params)
+				case METHOD_UPDATE_MANY_COLUMNS:
+					err = s.processUpdateManyColumns(crudParams, params)
 				case METHOD_UPDATE:
 					err = s.processUpdate(crudParams, params)
+				case METHOD_PATCH:
+					err = s.processPatch(crudParams, params)
+				case METHOD_INCREMENT:
+					err = s.processIncrement(crudParams, params)
+				case METHOD_DEQUEUE:
+					err = s.processDequeue(crudParams, params)
+				case METHOD_TREE:
+					err = s.processTree(crudParams, params)
 				case METHOD_DELETE:
 					err = s.processDelete(crudParams, params)
 				case METHOD_GET:
@@ -232,284 +276,1960 @@ func (s *crud) generateSQLForEachTable(crudParams config.CrudParams, params []ge
 					err = s.processTotal(crudParams, params)
 				case METHOD_EXISTS:
 					err = s.processExists(crudParams, params)
+				case METHOD_RESTORE, METHOD_HARD_DELETE:
+					err = ErrDerivedMethod
 				}
 
 				if err != nil {
-					return nil, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err)
+					return nil, nil, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err)
+				}
+
+				if err := checkQueries(queriesCompiler, builder.String()[start:]); err != nil {
+					queriesErrs = append(queriesErrs, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err))
 				}
+				queries = appendQueries(queries, methodType, builder.String()[start:])
 			}
 
-			result[tableName] = []byte(builder.String())
-		}
-	}
+			if tableParams.GetByUnique {
+				start := builder.Len()
+				params := processParams{
+					builder:     builder,
+					table:       tableName,
+					metaData:    *metaData,
+					tableParams: tableParams,
+					engine:      engineType(param.engine),
+					namedParams: crudParams.NamedParams,
+				}
 
-	return result, nil
-}
+				if err := s.processGetByUnique(crudParams, params); err != nil {
+					return nil, nil, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, "get_by_unique", tableName)+" error: %w", err)
+				}
 
-func (s *crud) getTableMeta(outputDir string) (tables, error) {
-	groupData := make(tables)
+				if err := checkQueries(queriesCompiler, builder.String()[start:]); err != nil {
+					queriesErrs = append(queriesErrs, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, "get_by_unique", tableName)+" error: %w", err))
+				}
+				queries = appendQueries(queries, "get_by_unique", builder.String()[start:])
+			}
 
-	catalog, ok := s.catalogs[outputDir]
-	if !ok {
-		return nil, fmt.Errorf("can not find catalog for output dir: %s", outputDir)
-	}
+			// Sort relation methods
+			relationKeys := make([]string, 0, len(tableParams.Relations))
+			for k := range tableParams.Relations {
+				relationKeys = append(relationKeys, k.String())
+			}
+			sort.Strings(relationKeys)
 
-	for _, schema := range catalog.Catalog.Schemas {
-		for _, table := range schema.Tables {
-			if _, ok := groupData[table.Rel.Name]; !ok {
-				groupData[table.Rel.Name] = &tableMetaData{}
+			for _, methodType := range relationKeys {
+				start := builder.Len()
+				params := processParams{
+					builder:      builder,
+					table:        tableName,
+					metaData:     *metaData,
+					methodParams: tableParams.Relations[config.MethodType(methodType)],
+					tableParams:  tableParams,
+					engine:       engineType(param.engine),
+					namedParams:  crudParams.NamedParams,
+				}
+
+				if err := s.processRelation(crudParams, config.MethodType(methodType), params); err != nil {
+					return nil, nil, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err)
+				}
+
+				if err := checkQueries(queriesCompiler, builder.String()[start:]); err != nil {
+					queriesErrs = append(queriesErrs, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err))
+				}
+				queries = appendQueries(queries, methodType, builder.String()[start:])
 			}
 
-			tableMeta := &tableMetaData{
-				columns: make([]string, len(table.Columns)),
+			// Sort update columns methods
+			updateColumnsKeys := make([]string, 0, len(tableParams.UpdateColumns))
+			for k := range tableParams.UpdateColumns {
+				updateColumnsKeys = append(updateColumnsKeys, k)
 			}
+			sort.Strings(updateColumnsKeys)
 
-			for i, column := range table.Columns {
-				tableMeta.columns[i] = column.Name
+			for _, key := range updateColumnsKeys {
+				start := builder.Len()
+				methodType := "update_columns_" + key
+				params := processParams{
+					builder:      builder,
+					table:        tableName,
+					metaData:     *metaData,
+					methodParams: tableParams.UpdateColumns[key],
+					tableParams:  tableParams,
+					engine:       engineType(param.engine),
+					namedParams:  crudParams.NamedParams,
+				}
+
+				if err := s.processUpdateColumns(crudParams, key, params); err != nil {
+					return nil, nil, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err)
+				}
+
+				if err := checkQueries(queriesCompiler, builder.String()[start:]); err != nil {
+					queriesErrs = append(queriesErrs, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, methodType, tableName)+" error: %w", err))
+				}
+				queries = appendQueries(queries, methodType, builder.String()[start:])
+			}
+
+			if manyToMany := tableParams.ManyToMany; manyToMany.Column != "" || manyToMany.RelatedColumn != "" {
+				start := builder.Len()
+				params := processParams{
+					builder:     builder,
+					table:       tableName,
+					metaData:    *metaData,
+					tableParams: tableParams,
+					engine:      engineType(param.engine),
+					namedParams: crudParams.NamedParams,
+				}
+
+				if err := s.processManyToMany(crudParams, params, tablesData, tablesParams); err != nil {
+					return nil, nil, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, "many_to_many", tableName)+" error: %w", err)
+				}
+
+				if err := checkQueries(queriesCompiler, builder.String()[start:]); err != nil {
+					queriesErrs = append(queriesErrs, fmt.Errorf(fmt.Sprintf(ErrWhileProcessTemplate, "many_to_many", tableName)+" error: %w", err))
+				}
+				queries = appendQueries(queries, "many_to_many", builder.String()[start:])
 			}
 
-			groupData[table.Rel.Name] = tableMeta
+			result[tableName] = queries
 		}
 	}
 
-	return groupData, nil
+	if len(queriesErrs) > 0 {
+		return nil, nil, fmt.Errorf("invalid generated queries: %w", errors.Join(queriesErrs...))
+	}
+
+	return result, resultTables, nil
 }
 
-func (s *crud) processCreate(cfg config.CrudParams, p processParams) error {
-	methodName := p.methodParams.Name
-	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_CREATE, p.table)
+// writeQueryName writes the query name with the description above it.
+// sqlc uses the description as a comment of the generated method.
+// Method description is used, otherwise the table description
+// or the table comment from the schema
+func writeQueryName(p processParams, name, operationType string) {
+	description := p.methodParams.Description
+	if description == "" {
+		description = p.tableParams.Description
 	}
-
-	operationType := "exec"
-	if p.methodParams.Returning != "" {
-		operationType = "one"
+	if description == "" {
+		description = p.metaData.comment
 	}
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :%s\n", methodName, operationType))
-	p.builder.WriteString("INSERT INTO ")
-	p.builder.WriteString(p.table)
-	p.builder.WriteString(" (")
-
-	filteredColumns := cmnutils.FilterValues(p.metaData.columns, p.methodParams.SkipColumns)
-	for index, name := range filteredColumns {
-		if index > 0 && index < len(filteredColumns) {
-			p.builder.WriteString(", ")
+	description = strings.TrimSpace(description)
+	if description != "" {
+		for _, line := range strings.Split(description, "\n") {
+			// empty line separates paragraphs of the comment
+			p.builder.WriteString(strings.TrimRight("-- "+strings.TrimSpace(line), " ") + "\n")
 		}
+	}
+
+	p.builder.WriteString(fmt.Sprintf("-- name: %s :%s\n", name, operationType))
+}
 
-		p.builder.WriteString(name)
+// appendQueries adds generated queries of the method if they are not empty
+func appendQueries(queries []generatedQueries, method, data string) []generatedQueries {
+	if strings.TrimSpace(data) == "" {
+		return queries
 	}
-	p.builder.WriteString(")\n\tVALUES (")
 
-	lastIndex := 1
-	for index, name := range filteredColumns {
-		if index > 0 && index < len(filteredColumns) {
-			p.builder.WriteString(", ")
-		}
+	return append(queries, generatedQueries{method: method, data: []byte(data)})
+}
 
-		if len(p.methodParams.ColumnValues) > 0 {
-			if value, ok := p.methodParams.ColumnValues[name]; ok {
-				p.builder.WriteString(value)
-				continue
-			}
-		}
+// checkQueries parses and analyzes generated queries with sqlc compiler
+func checkQueries(c *compiler.Compiler, src string) error {
+	if c == nil || strings.TrimSpace(src) == "" {
+		return nil
+	}
 
-		switch p.engine {
-		case EngineTypePostgres:
-			_, err := fmt.Fprintf(p.builder, "$%d", lastIndex)
-			if err != nil {
-				return err
-			}
-		case EngineTypeMysql, EngineTypeSqlite:
-			_, err := fmt.Fprintf(p.builder, "?")
-			if err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("engine %s is not supported", p.engine)
-		}
+	_, err := c.ParseQueriesSource(src, opts.Parser{})
 
-		lastIndex++
+	var merr *multierr.Error
+	if !errors.As(err, &merr) {
+		return err
 	}
 
-	p.builder.WriteString(")")
-	if p.methodParams.Returning != "" {
-		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	errs := make([]error, 0, len(merr.Errs()))
+	for _, item := range merr.Errs() {
+		errs = append(errs, fmt.Errorf("line %d:%d: %w", item.Line, item.Column, item.Err))
 	}
-	p.builder.WriteString(";\n\n")
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func (s *crud) processUpdate(cfg config.CrudParams, p processParams) error {
-	primaryColumn, err := getPrimaryColumn(p.metaData.columns, p.table, p.tableParams.PrimaryColumn)
-	if err != nil {
-		return err
-	}
+// resolveTables returns params for each table to generate.
+// Tables configured by name are used as is, other tables of the catalog
+// get params of the first matching pattern key in sorted order.
+// Default methods are merged into methods of each table.
+// Tables of the default schema can be configured with or without the schema
+func resolveTables(crudParams config.CrudParams, data tables, defaultSchema string) (config.Table, error) {
+	res := make(config.Table, len(crudParams.Tables))
+
+	patterns := make([]string, 0)
+	for key, tableParams := range crudParams.Tables {
+		if isTablePattern(key) {
+			patterns = append(patterns, key)
+			continue
+		}
 
-	if primaryColumn == "" {
-		return ErrUndefinedPrimaryColumn
-	}
+		tableName := key
+		if defaultSchema != "" {
+			tableName = strings.TrimPrefix(key, defaultSchema+".")
+		}
+		if _, ok := res[tableName]; ok {
+			return nil, fmt.Errorf("table %s is configured twice: with and without the default schema", tableName)
+		}
 
-	methodName := p.methodParams.Name
-	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_UPDATE, p.table)
+		tableParams.Methods = mergeMethods(crudParams.Default.Methods, tableParams.Methods)
+		res[tableName] = tableParams
 	}
+	sort.Strings(patterns)
 
-	operationType := "exec"
-	if p.methodParams.Returning != "" {
-		operationType = "one"
+	tableNames := make([]string, 0, len(data))
+	for name := range data {
+		tableNames = append(tableNames, name)
 	}
+	sort.Strings(tableNames)
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :%s\n", methodName, operationType))
-	p.builder.WriteString("UPDATE ")
-	p.builder.WriteString(p.table)
-	p.builder.WriteString("\n\tSET ")
+	for _, pattern := range patterns {
+		tableParams := crudParams.Tables[pattern]
 
-	lastIndex := 1
-	filteredColumns := cmnutils.FilterValues(p.metaData.columns, p.methodParams.SkipColumns)
-	for index, name := range filteredColumns {
-		if index > 0 && index < len(filteredColumns) {
-			p.builder.WriteString(", ")
-			if len(p.metaData.columns) > 6 && index%6 == 0 {
-				p.builder.WriteString("\n\t\t")
+		for _, tableName := range tableNames {
+			if _, ok := res[tableName]; ok {
+				continue
 			}
-		}
 
-		if len(p.methodParams.ColumnValues) > 0 {
-			if value, ok := p.methodParams.ColumnValues[name]; ok {
-				p.builder.WriteString(name + "=" + value)
+			ok, err := matchTableName(pattern, tableName, defaultSchema)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
 				continue
 			}
-		}
 
-		switch p.engine {
-		case EngineTypePostgres:
-			_, err := fmt.Fprintf(p.builder, "%s=$%d", name, lastIndex)
-			if err != nil {
-				return err
+			excluded := false
+			for _, item := range tableParams.Exclude {
+				ok, err := matchTableName(item, tableName, defaultSchema)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					excluded = true
+					break
+				}
 			}
-		case EngineTypeMysql, EngineTypeSqlite:
-			_, err := fmt.Fprintf(p.builder, "%s=?", name)
-			if err != nil {
-				return err
+			if excluded {
+				continue
 			}
-		default:
-			return fmt.Errorf("engine %s is not supported", p.engine)
-		}
 
-		lastIndex++
+			params := tableParams
+			params.Exclude = nil
+			params.Methods = mergeMethods(crudParams.Default.Methods, tableParams.Methods)
+			res[tableName] = params
+		}
 	}
 
-	p.builder.WriteString("\n\t")
-	p.methodParams.AddWhereParam(primaryColumn, config.WhereParamsItem{})
+	return res, nil
+}
 
-	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
-		return err
+// isTablePattern reports whether the table key is a glob pattern or a regular expression in slashes
+func isTablePattern(key string) bool {
+	return isTableRegexp(key) || strings.ContainsAny(key, "*?[")
+}
+
+func isTableRegexp(key string) bool {
+	return len(key) > 2 && strings.HasPrefix(key, "/") && strings.HasSuffix(key, "/")
+}
+
+// matchTable reports whether the table name matches the table key
+func matchTable(key, tableName string) (bool, error) {
+	if isTableRegexp(key) {
+		re, err := regexp.Compile(key[1 : len(key)-1])
+		if err != nil {
+			return false, fmt.Errorf("invalid table pattern %s: %w", key, err)
+		}
+		return re.MatchString(tableName), nil
 	}
 
-	if p.methodParams.Returning != "" {
-		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	ok, err := path.Match(key, tableName)
+	if err != nil {
+		return false, fmt.Errorf("invalid table pattern %s: %w", key, err)
 	}
-	p.builder.WriteString(";\n\n")
 
-	return nil
+	return ok, nil
 }
 
-func (s *crud) processDelete(cfg config.CrudParams, p processParams) error {
-	primaryColumn, err := getPrimaryColumn(p.metaData.columns, p.table, p.tableParams.PrimaryColumn)
-	if err != nil {
-		return err
+// matchTableName reports whether the table key matches the table name.
+// Tables of the default schema also match keys qualified by the default schema
+func matchTableName(key, tableName, defaultSchema string) (bool, error) {
+	ok, err := matchTable(key, tableName)
+	if err != nil || ok || defaultSchema == "" || strings.Contains(tableName, ".") {
+		return ok, err
 	}
 
-	if primaryColumn == "" {
-		return ErrUndefinedPrimaryColumn
-	}
+	return matchTable(key, defaultSchema+"."+tableName)
+}
 
-	methodName := p.methodParams.Name
-	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_DELETE, p.table)
+// mergeMethods merges methods in order, later methods override earlier ones.
+// A method without params keeps params of the overridden method
+func mergeMethods(items ...map[config.MethodType]config.Method) map[config.MethodType]config.Method {
+	res := make(map[config.MethodType]config.Method)
+	for _, methods := range items {
+		for methodType, method := range expandMethods(methods) {
+			if prev, ok := res[methodType]; ok && reflect.ValueOf(method).IsZero() {
+				method = prev
+			}
+			res[methodType] = cloneMethod(method)
+		}
 	}
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :exec\n", methodName))
-	p.builder.WriteString("DELETE FROM ")
-	p.builder.WriteString(p.table)
+	return res
+}
 
-	lastIndex := 1
-	p.methodParams.AddWhereParam(primaryColumn, config.WhereParamsItem{})
+// expandMethods replaces METHOD_ALL with allMethods.
+// Methods specified explicitly override params of METHOD_ALL
+func expandMethods(methods map[config.MethodType]config.Method) map[config.MethodType]config.Method {
+	res := make(map[config.MethodType]config.Method, len(methods))
+	if method, ok := methods[METHOD_ALL]; ok {
+		method.Name = ""
+		for _, methodType := range allMethods {
+			res[methodType] = cloneMethod(method)
+		}
+	}
 
-	if err := s.processWhereParam(p, METHOD_DELETE, &lastIndex); err != nil {
-		return err
+	for methodType, method := range methods {
+		if methodType == METHOD_ALL {
+			continue
+		}
+		res[methodType] = method
 	}
-	p.builder.WriteString(";\n\n")
 
-	return nil
+	return res
 }
 
-func (s *crud) processGet(cfg config.CrudParams, p processParams) error {
-	primaryColumn, err := getPrimaryColumn(p.metaData.columns, p.table, p.tableParams.PrimaryColumn)
-	if err != nil {
-		return err
-	}
+// cloneMethod returns a copy of the method which does not share maps and slices with the original,
+// so params of one table or method can not leak into another one
+func cloneMethod(method config.Method) config.Method {
+	method.Where = maps.Clone(method.Where)
+	method.ColumnValues = maps.Clone(method.ColumnValues)
+	method.WhereAdditional = slices.Clone(method.WhereAdditional)
+	method.SkipColumns = slices.Clone(method.SkipColumns)
+	method.ConflictColumns = slices.Clone(method.ConflictColumns)
+	method.UpdateColumns = slices.Clone(method.UpdateColumns)
+	method.SelectColumns = slices.Clone(method.SelectColumns)
+	method.ExcludeColumns = slices.Clone(method.ExcludeColumns)
+
+	return method
+}
 
-	if primaryColumn == "" {
-		return ErrUndefinedPrimaryColumn
+func (s *crud) getTableMeta(outputDir string) (tables, error) {
+	groupData := make(tables)
+
+	item, ok := s.catalogs[outputDir]
+	if !ok {
+		return nil, fmt.Errorf("can not find catalog for output dir: %s", outputDir)
 	}
 
-	methodName := p.methodParams.Name
-	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_GET, p.table)
+	// count tables with the same name in different schemas
+	names := make(map[string]int)
+	for _, schema := range item.Catalog.Schemas {
+		for _, table := range schema.Tables {
+			names[table.Rel.Name]++
+		}
 	}
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :one\n", methodName))
-	p.builder.WriteString("SELECT * FROM ")
-	p.builder.WriteString(p.table)
+	for _, schema := range item.Catalog.Schemas {
+		for _, table := range schema.Tables {
+			tableMeta := &tableMetaData{
+				name:           table.Rel.Name,
+				columns:        make([]string, len(table.Columns)),
+				primaryColumns: table.PrimaryKey,
+				comment:        table.Comment,
+			}
 
-	lastIndex := 1
-	p.methodParams.AddWhereParam(primaryColumn, config.WhereParamsItem{})
+			// tables of other schemas are qualified by the schema name
+			key := table.Rel.Name
+			if schema.Name != item.Catalog.DefaultSchema {
+				key = schema.Name + "." + table.Rel.Name
+				if names[table.Rel.Name] > 1 {
+					tableMeta.name = schema.Name + "_" + table.Rel.Name
+				}
+			}
 
-	if err := s.processWhereParam(p, METHOD_GET, &lastIndex); err != nil {
-		return err
+			tableMeta.columnTypes = make(map[string]string, len(table.Columns))
+			for i, column := range table.Columns {
+				tableMeta.columns[i] = column.Name
+				tableMeta.columnTypes[column.Name] = getColumnType(column, item.Catalog.DefaultSchema)
+			}
+
+			tableMeta.uniqueKeys = getUniqueKeys(table, tableMeta.columns)
+
+			for _, fk := range table.ForeignKeys {
+				refTable := fk.RefTable.Name
+				if fk.RefTable.Schema != "" && fk.RefTable.Schema != item.Catalog.DefaultSchema {
+					refTable = fk.RefTable.Schema + "." + refTable
+				}
+
+				tableMeta.foreignKeys = append(tableMeta.foreignKeys, foreignKey{
+					columns:    fk.Columns,
+					refTable:   refTable,
+					refColumns: fk.RefColumns,
+				})
+			}
+
+			groupData[key] = tableMeta
+		}
 	}
-	p.builder.WriteString(" LIMIT 1;\n\n")
 
-	return nil
+	return groupData, nil
 }
 
-func (s *crud) processFind(cfg config.CrudParams, p processParams) error {
-	methodName := p.methodParams.Name
-	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_FIND, p.table)
+func (s *crud) processCreate(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
 	}
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :many\n", methodName))
-	p.builder.WriteString("SELECT * FROM ")
-	p.builder.WriteString(p.table)
-	lastIndex := 1
-	if err := s.processWhereParam(p, METHOD_FIND, &lastIndex); err != nil {
+	if err := checkReturning(p, d); err != nil {
 		return err
 	}
-	if order := getOrderByParams(p.methodParams); order != nil {
-		p.builder.WriteString(fmt.Sprintf(" ORDER BY %s %s", order.By, order.Direction))
-	}
-	if p.methodParams.Limit {
-		p.builder.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", lastIndex, lastIndex+1))
-	}
-	p.builder.WriteString(";\n\n")
-	return nil
-}
 
-func (s *crud) processTotal(cfg config.CrudParams, p processParams) error {
 	methodName := p.methodParams.Name
 	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_TOTAL, p.table)
+		methodName = s.getMethodName(cfg, METHOD_CREATE, p.tableName())
 	}
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :one\n", methodName))
-	p.builder.WriteString("SELECT count(1) as total FROM ")
-	p.builder.WriteString(p.table)
-	lastIndex := 1
-	if err := s.processWhereParam(p, METHOD_TOTAL, &lastIndex); err != nil {
+	operationType := "exec"
+	if p.methodParams.Returning != "" {
+		operationType = "one"
+	}
+
+	writeQueryName(p, methodName, operationType)
+	if _, err := s.processInsert(p); err != nil {
+		return err
+	}
+
+	if p.methodParams.Returning != "" {
+		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+// processInsert writes INSERT INTO statement and returns inserted columns
+func (s *crud) processInsert(p processParams) ([]string, error) {
+	d, err := p.dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	p.builder.WriteString("INSERT INTO ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString(" (")
+
+	skipColumns := p.methodParams.SkipColumns
+	// version column is filled by default value, unless column value is set
+	if column := p.tableParams.VersionColumn; column != "" {
+		if _, ok := p.methodParams.ColumnValues[column]; !ok {
+			skipColumns = append(slices.Clone(skipColumns), column)
+		}
+	}
+
+	filteredColumns := cmnutils.FilterValues(p.metaData.columns, skipColumns)
+	p.builder.WriteString(d.idents(filteredColumns))
+	p.builder.WriteString(")\n\tVALUES (")
+
+	lastIndex := 1
+	for index, name := range filteredColumns {
+		if index > 0 && index < len(filteredColumns) {
+			p.builder.WriteString(", ")
+		}
+
+		if len(p.methodParams.ColumnValues) > 0 {
+			if value, ok := p.methodParams.ColumnValues[name]; ok {
+				p.builder.WriteString(value)
+				continue
+			}
+		}
+
+		if p.namedParams {
+			p.builder.WriteString("sqlc.arg('" + name + "')")
+			continue
+		}
+
+		p.builder.WriteString(d.placeholder(lastIndex))
+		lastIndex++
+	}
+
+	p.builder.WriteString(")")
+
+	return filteredColumns, nil
+}
+
+func (s *crud) processCreateBulk(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if !d.copyFrom {
+		return fmt.Errorf("copyfrom is not supported by %s", p.engine)
+	}
+
+	// copyfrom supports only params in VALUES
+	if len(p.methodParams.ColumnValues) > 0 {
+		return fmt.Errorf("column_values are not supported by copyfrom")
+	}
+	if p.methodParams.Returning != "" {
+		return fmt.Errorf("returning is not supported by copyfrom")
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_CREATE_BULK, p.tableName())
+	}
+
+	writeQueryName(p, methodName, "copyfrom")
+	if _, err := s.processInsert(p); err != nil {
+		return err
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+// processBatch generates get, update or delete method with batch query type.
+// Batch queries are supported only by pgx driver
+func (s *crud) processBatch(cfg config.CrudParams, methodType config.MethodType, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if !d.batch {
+		return fmt.Errorf("batch queries are not supported by %s", p.engine)
+	}
+
+	if p.methodParams.Name == "" {
+		p.methodParams.Name = s.getMethodName(cfg, methodType, p.tableName())
+	}
+	p.batch = true
+
+	switch methodType {
+	case METHOD_BATCH_GET:
+		return s.processGet(cfg, p)
+	case METHOD_BATCH_UPDATE:
+		return s.processUpdate(cfg, p)
+	case METHOD_BATCH_DELETE:
+		return s.processDelete(cfg, p)
+	}
+
+	return fmt.Errorf("unsupported batch method %s", methodType)
+}
+
+// getManyMethodName returns method name for many rows with the table name in plural form.
+// Example: GetManyUsers
+func (s *crud) getManyMethodName(cfg config.CrudParams, prefix string, p processParams) string {
+	if p.methodParams.Name != "" {
+		return p.methodParams.Name
+	}
+
+	return formatMethodName(cfg, prefix, p.tableName(), true)
+}
+
+// withPrimaryKeyArray adds where param for the array of primary keys.
+// Example: id = ANY(sqlc.arg('id')::uuid[]) for postgresql, id IN (sqlc.slice('id')) for mysql and sqlite
+func withPrimaryKeyArray(p processParams) (processParams, error) {
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return p, err
+	}
+
+	if len(primaryColumns) > 1 {
+		return p, fmt.Errorf("composite primary key of table %s is not supported", p.table)
+	}
+
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	p.methodParams.AddWhereParam(primaryColumns[0], config.WhereParamsItem{Operator: WHERE_OPERATOR_IN})
+
+	return p, nil
+}
+
+// processGetMany generates method which returns rows by primary keys
+func (s *crud) processGetMany(cfg config.CrudParams, p processParams) error {
+	p, err := withPrimaryKeyArray(p)
+	if err != nil {
+		return err
+	}
+	p.methodParams.Name = s.getManyMethodName(cfg, "GetMany", p)
+
+	return s.processFind(cfg, p)
+}
+
+// processDeleteMany generates method which deletes rows by primary keys
+func (s *crud) processDeleteMany(cfg config.CrudParams, p processParams) error {
+	softDeleteColumn, err := getSoftDeleteColumn(p)
+	if err != nil {
+		return err
+	}
+
+	p, err = withPrimaryKeyArray(p)
+	if err != nil {
+		return err
+	}
+
+	writeQueryName(p, s.getManyMethodName(cfg, "DeleteMany", p), "execrows")
+
+	return s.processDeleteStatement(p, softDeleteColumn)
+}
+
+// processUpdateManyColumns generates method which sets update columns for rows by primary keys
+func (s *crud) processUpdateManyColumns(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if err := checkReturning(p, d); err != nil {
+		return err
+	}
+
+	if len(p.methodParams.UpdateColumns) == 0 {
+		return fmt.Errorf("update_columns are required")
+	}
+
+	for _, column := range p.methodParams.UpdateColumns {
+		if !slices.Contains(p.metaData.columns, column) {
+			return fmt.Errorf("column %s does not exist in table %s", column, p.table)
+		}
+	}
+
+	versionColumn, err := getVersionColumn(p)
+	if err != nil {
+		return err
+	}
+
+	p, err = withPrimaryKeyArray(p)
+	if err != nil {
+		return err
+	}
+
+	operationType := "execrows"
+	if p.methodParams.Returning != "" {
+		operationType = "many"
+	}
+
+	// update columns and columns with values in the order of the table
+	columns := make([]string, 0, len(p.methodParams.UpdateColumns))
+	for _, column := range p.metaData.columns {
+		_, hasValue := p.methodParams.ColumnValues[column]
+		if hasValue || column == versionColumn || slices.Contains(p.methodParams.UpdateColumns, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	writeQueryName(p, s.getManyMethodName(cfg, "UpdateMany", p), operationType)
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET ")
+
+	for index, name := range columns {
+		if index > 0 {
+			p.builder.WriteString(", ")
+		}
+
+		column := d.ident(name)
+		switch value, ok := p.methodParams.ColumnValues[name]; {
+		case ok:
+			p.builder.WriteString(column + "=" + value)
+		case name == versionColumn:
+			p.builder.WriteString(column + "=" + column + "+1")
+		default:
+			// sqlite mixes up numbers of positional and named params, so all params are named
+			p.builder.WriteString(column + "=sqlc.arg('" + name + "')")
+		}
+	}
+	p.builder.WriteString("\n\t")
+
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
+		return err
+	}
+
+	if p.methodParams.Returning != "" {
+		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+// processIncrement generates race free update of counters: SET balance=balance+sqlc.arg('balance').
+// Guard is checked for the new value of each column and new values are returned
+func (s *crud) processIncrement(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if err := checkReturning(p, d); err != nil {
+		return err
+	}
+
+	columns := p.methodParams.UpdateColumns
+	if len(columns) == 0 {
+		return fmt.Errorf("update_columns are required")
+	}
+
+	for _, column := range columns {
+		if !slices.Contains(p.metaData.columns, column) {
+			return fmt.Errorf("column %s does not exist in table %s", column, p.table)
+		}
+	}
+
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return err
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_INCREMENT, p.tableName())
+		if len(columns) == 1 {
+			methodName += stringy.New(columns[0]).CamelCase().UcFirst()
+		}
+	}
+
+	// new values are returned by default
+	returning := p.methodParams.Returning
+	if returning == "" && d.returning {
+		returning = d.idents(columns)
+	}
+
+	operationType := "execrows"
+	if returning != "" {
+		operationType = "one"
+	}
+
+	// delta params are reused in guard, so all params are named
+	p.namedParams = true
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	p.methodParams.WhereAdditional = slices.Clone(p.methodParams.WhereAdditional)
+
+	writeQueryName(p, methodName, operationType)
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET ")
+
+	index := 0
+	for _, name := range p.metaData.columns {
+		column := d.ident(name)
+		value, hasValue := p.methodParams.ColumnValues[name]
+		switch {
+		case slices.Contains(columns, name):
+			value = column + "+sqlc.arg('" + name + "')"
+			if p.methodParams.Guard != "" {
+				p.methodParams.WhereAdditional = append(p.methodParams.WhereAdditional, value+" "+p.methodParams.Guard)
+			}
+		case !hasValue:
+			continue
+		}
+
+		if index > 0 {
+			p.builder.WriteString(", ")
+		}
+		p.builder.WriteString(column + "=" + value)
+		index++
+	}
+	p.builder.WriteString("\n\t")
+
+	for _, column := range primaryColumns {
+		p.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+	}
+
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
+		return err
+	}
+
+	if returning != "" {
+		p.builder.WriteString("\n\tRETURNING " + returning)
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+func (s *crud) processUpsert(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	conflictColumns := p.methodParams.ConflictColumns
+	if len(conflictColumns) == 0 {
+		primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+		if err != nil {
+			return err
+		}
+		conflictColumns = primaryColumns
+	}
+
+	for _, column := range slices.Concat(conflictColumns, p.methodParams.UpdateColumns) {
+		if !slices.Contains(p.metaData.columns, column) {
+			return fmt.Errorf("column %s does not exist in table %s", column, p.table)
+		}
+	}
+
+	if err := checkReturning(p, d); err != nil {
+		return err
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_UPSERT, p.tableName())
+	}
+
+	operationType := "exec"
+	if p.methodParams.Returning != "" {
+		operationType = "one"
+	}
+
+	writeQueryName(p, methodName, operationType)
+	insertedColumns, err := s.processInsert(p)
+	if err != nil {
+		return err
+	}
+
+	// by default all inserted columns except conflict columns are updated
+	updateColumns := p.methodParams.UpdateColumns
+	if len(updateColumns) == 0 {
+		updateColumns = cmnutils.FilterValues(insertedColumns, conflictColumns)
+	}
+
+	versionColumn, err := getVersionColumn(p)
+	if err != nil {
+		return err
+	}
+	updateColumns = slices.DeleteFunc(slices.Clone(updateColumns), func(c string) bool {
+		return c == versionColumn
+	})
+
+	if !d.onDuplicateKey {
+		p.builder.WriteString("\n\tON CONFLICT (" + d.idents(conflictColumns) + ")")
+		if len(updateColumns) == 0 {
+			p.builder.WriteString(" DO NOTHING")
+		} else {
+			p.builder.WriteString(" DO UPDATE\n\tSET ")
+			for index, name := range updateColumns {
+				if index > 0 {
+					p.builder.WriteString(", ")
+					if index%6 == 0 {
+						p.builder.WriteString("\n\t\t")
+					}
+				}
+				p.builder.WriteString(d.ident(name) + "=EXCLUDED." + d.ident(name))
+			}
+			if versionColumn != "" {
+				p.builder.WriteString(", " + d.ident(versionColumn) + "=" + d.ident(p.table) + "." + d.ident(versionColumn) + "+1")
+			}
+		}
+	} else {
+		p.builder.WriteString("\n\tON DUPLICATE KEY UPDATE ")
+		if len(updateColumns) == 0 {
+			// mysql does not support DO NOTHING, so the key is updated with its own value
+			p.builder.WriteString(d.ident(conflictColumns[0]) + "=" + d.ident(conflictColumns[0]))
+		} else {
+			for index, name := range updateColumns {
+				if index > 0 {
+					p.builder.WriteString(", ")
+					if index%6 == 0 {
+						p.builder.WriteString("\n\t\t")
+					}
+				}
+				p.builder.WriteString(d.ident(name) + "=VALUES(" + d.ident(name) + ")")
+			}
+			if versionColumn != "" {
+				p.builder.WriteString(", " + d.ident(versionColumn) + "=" + d.ident(versionColumn) + "+1")
+			}
+		}
+	}
+
+	if p.methodParams.Returning != "" {
+		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+// processUpdateColumns generates update method which sets only update columns and column values by primary key.
+// Example: UpdateUserStatus
+func (s *crud) processUpdateColumns(cfg config.CrudParams, key string, p processParams) error {
+	updateColumns := p.methodParams.UpdateColumns
+	if len(updateColumns) == 0 {
+		updateColumns = []string{key}
+	}
+
+	for _, column := range updateColumns {
+		if !slices.Contains(p.metaData.columns, column) {
+			return fmt.Errorf("column %s does not exist in table %s", column, p.table)
+		}
+	}
+
+	if p.methodParams.Name == "" {
+		p.methodParams.Name = s.getMethodName(cfg, METHOD_UPDATE, p.tableName()) + stringy.New(key).CamelCase().UcFirst()
+	}
+
+	// all other columns are skipped. Version column is handled by update method
+	p.methodParams.SkipColumns = slices.DeleteFunc(slices.Clone(p.metaData.columns), func(c string) bool {
+		_, hasValue := p.methodParams.ColumnValues[c]
+		return hasValue || slices.Contains(updateColumns, c)
+	})
+
+	return s.processUpdate(cfg, p)
+}
+
+func (s *crud) processUpdate(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if err := checkReturning(p, d); err != nil {
+		return err
+	}
+
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return err
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_UPDATE, p.tableName())
+	}
+
+	versionColumn, err := getVersionColumn(p)
+	if err != nil {
+		return err
+	}
+
+	operationType := "exec"
+	if p.methodParams.Returning != "" {
+		operationType = "one"
+	}
+
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	if versionColumn != "" {
+		// the version is always incremented and checked, so lost update can be detected by affected rows
+		p.methodParams.SkipColumns = slices.DeleteFunc(slices.Clone(p.methodParams.SkipColumns), func(c string) bool {
+			return c == versionColumn
+		})
+		p.methodParams.ColumnValues = maps.Clone(p.methodParams.ColumnValues)
+		if p.methodParams.ColumnValues == nil {
+			p.methodParams.ColumnValues = make(map[string]string, 1)
+		}
+		p.methodParams.ColumnValues[versionColumn] = d.ident(versionColumn) + "+1"
+		p.methodParams.AddWhereParam(versionColumn, config.WhereParamsItem{})
+
+		if operationType == "exec" {
+			// sqlc has no :batchexecrows, so a lost update of batch query can be detected only by returning
+			if p.batch {
+				return fmt.Errorf("batch_update with version_column requires returning")
+			}
+			operationType = "execrows"
+		}
+	}
+
+	if p.batch {
+		operationType = "batch" + operationType
+	}
+
+	for _, column := range primaryColumns {
+		p.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+	}
+
+	writeQueryName(p, methodName, operationType)
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET ")
+
+	lastIndex := 1
+	filteredColumns := cmnutils.FilterValues(p.metaData.columns, p.methodParams.SkipColumns)
+	for index, name := range filteredColumns {
+		if index > 0 && index < len(filteredColumns) {
+			p.builder.WriteString(", ")
+			if len(p.metaData.columns) > 6 && index%6 == 0 {
+				p.builder.WriteString("\n\t\t")
+			}
+		}
+
+		if len(p.methodParams.ColumnValues) > 0 {
+			if value, ok := p.methodParams.ColumnValues[name]; ok {
+				p.builder.WriteString(d.ident(name) + "=" + value)
+				continue
+			}
+		}
+
+		if p.namedParams {
+			// the column is also compared in WHERE, so new value has a separate param
+			param := name
+			if _, ok := p.methodParams.Where[name]; ok {
+				param = "new_" + name
+			}
+			p.builder.WriteString(d.ident(name) + "=sqlc.arg('" + param + "')")
+			continue
+		}
+
+		p.builder.WriteString(d.ident(name) + "=" + d.placeholder(lastIndex))
+		lastIndex++
+	}
+
+	p.builder.WriteString("\n\t")
+	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
+		return err
+	}
+
+	if p.methodParams.Returning != "" {
+		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+// processPatch generates update method which changes only provided columns
+func (s *crud) processPatch(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if err := checkReturning(p, d); err != nil {
+		return err
+	}
+
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return err
+	}
+
+	versionColumn, err := getVersionColumn(p)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range p.methodParams.UpdateColumns {
+		if !slices.Contains(p.metaData.columns, column) {
+			return fmt.Errorf("column %s does not exist in table %s", column, p.table)
+		}
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_PATCH, p.tableName())
+	}
+
+	operationType := "exec"
+	switch {
+	case p.methodParams.Returning != "":
+		operationType = "one"
+	case versionColumn != "":
+		operationType = "execrows"
+	}
+
+	// by default all columns except skipped are patched.
+	// Columns with values are always updated
+	columns := make([]string, 0, len(p.metaData.columns))
+	for _, column := range p.metaData.columns {
+		if slices.Contains(primaryColumns, column) || column == versionColumn {
+			continue
+		}
+
+		_, hasValue := p.methodParams.ColumnValues[column]
+		switch {
+		case hasValue:
+		case len(p.methodParams.UpdateColumns) > 0 && !slices.Contains(p.methodParams.UpdateColumns, column):
+			continue
+		case slices.Contains(p.methodParams.SkipColumns, column):
+			continue
+		}
+
+		columns = append(columns, column)
+	}
+	if versionColumn != "" {
+		columns = append(columns, versionColumn)
+	}
+
+	writeQueryName(p, methodName, operationType)
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET ")
+
+	for index, name := range columns {
+		if index > 0 {
+			p.builder.WriteString(",\n\t\t")
+		}
+
+		column := d.ident(name)
+		if value, ok := p.methodParams.ColumnValues[name]; ok {
+			p.builder.WriteString(column + "=" + value)
+			continue
+		}
+
+		if name == versionColumn {
+			p.builder.WriteString(column + "=" + column + "+1")
+			continue
+		}
+
+		_, err := fmt.Fprintf(p.builder, "%s=COALESCE(sqlc.narg('%s'), %s)", column, name, column)
+		if err != nil {
+			return err
+		}
+	}
+
+	// positional params can not be mixed with sqlc.narg in sqlite
+	p.namedParams = true
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	for _, column := range primaryColumns {
+		p.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+	}
+	if versionColumn != "" {
+		p.methodParams.AddWhereParam(versionColumn, config.WhereParamsItem{})
+	}
+
+	p.builder.WriteString("\n\t")
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
+		return err
+	}
+
+	if p.methodParams.Returning != "" {
+		p.builder.WriteString("\n\tRETURNING " + p.methodParams.Returning)
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+func (s *crud) processDelete(cfg config.CrudParams, p processParams) error {
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return err
+	}
+
+	softDeleteColumn, err := getSoftDeleteColumn(p)
+	if err != nil {
+		return err
+	}
+	if p.hardDelete {
+		softDeleteColumn = ""
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_DELETE, p.tableName())
+	}
+
+	operationType := "exec"
+	if p.batch {
+		operationType = "batchexec"
+	}
+
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	for _, column := range primaryColumns {
+		p.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+	}
+
+	writeQueryName(p, methodName, operationType)
+
+	if err := s.processDeleteStatement(p, softDeleteColumn); err != nil {
+		return err
+	}
+
+	if softDeleteColumn == "" || p.batch {
+		return nil
+	}
+
+	if err := s.processRestore(cfg, p, primaryColumns, softDeleteColumn); err != nil {
+		return err
+	}
+
+	hardDeleteParams := p
+	hardDeleteParams.hardDelete = true
+	hardDeleteParams.methodParams = config.Method{
+		Name: s.getMethodName(cfg, METHOD_HARD_DELETE, p.tableName()),
+	}
+
+	return s.processDelete(cfg, hardDeleteParams)
+}
+
+// processDeleteStatement writes DELETE statement or UPDATE statement
+// which marks rows as deleted if soft delete column is set
+func (s *crud) processDeleteStatement(p processParams, softDeleteColumn string) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	lastIndex := 1
+	if softDeleteColumn == "" {
+		p.builder.WriteString("DELETE FROM ")
+		p.builder.WriteString(d.ident(p.table))
+
+		if err := s.processWhereParam(p, METHOD_DELETE, &lastIndex); err != nil {
+			return err
+		}
+		p.builder.WriteString(";\n\n")
+
+		return nil
+	}
+
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET " + d.ident(softDeleteColumn) + "=" + getSoftDeleteValue(p, d) + "\n\t")
+
+	// already deleted rows keep the original deletion time
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	if _, ok := p.methodParams.Where[softDeleteColumn]; !ok {
+		p.methodParams.AddWhereParam(softDeleteColumn, config.WhereParamsItem{Value: "IS NULL"})
+	}
+
+	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
+		return err
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+// processRestore generates method which clears soft delete column
+func (s *crud) processRestore(cfg config.CrudParams, p processParams, primaryColumns []string, softDeleteColumn string) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	p.methodParams = config.Method{}
+	for _, column := range primaryColumns {
+		p.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+	}
+
+	writeQueryName(p, s.getMethodName(cfg, METHOD_RESTORE, p.tableName()), "exec")
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET " + d.ident(softDeleteColumn) + "=NULL\n\t")
+
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_UPDATE, &lastIndex); err != nil {
+		return err
+	}
+	p.builder.WriteString(";\n\n")
+
+	return nil
+}
+
+func (s *crud) processGet(cfg config.CrudParams, p processParams) error {
+	p, err := withSoftDeleteFilter(p)
+	if err != nil {
+		return err
+	}
+
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return err
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_GET, p.tableName())
+	}
+
+	operationType := "one"
+	if p.batch {
+		operationType = "batchone"
+	}
+
+	selectColumns, err := getSelectColumns(p, d)
+	if err != nil {
+		return err
+	}
+
+	lock, err := getLockClause(p, d)
+	if err != nil {
+		return err
+	}
+
+	writeQueryName(p, methodName, operationType)
+	p.builder.WriteString("SELECT " + selectColumns + " FROM ")
+	p.builder.WriteString(d.ident(p.table))
+
+	lastIndex := 1
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	for _, column := range primaryColumns {
+		p.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+	}
+
+	if err := s.processWhereParam(p, METHOD_GET, &lastIndex); err != nil {
+		return err
+	}
+	p.builder.WriteString(" LIMIT 1" + lock + ";\n\n")
+
+	return nil
+}
+
+func (s *crud) processGetByUnique(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range p.metaData.uniqueKeys {
+		methodName := s.getMethodName(cfg, METHOD_GET, p.tableName()) + "By" + getColumnsMethodSuffix(key.columns)
+
+		writeQueryName(p, methodName, "one")
+		p.builder.WriteString("SELECT * FROM ")
+		p.builder.WriteString(d.ident(p.table))
+
+		params := p
+		params.methodParams = config.Method{}
+		for _, column := range key.columns {
+			params.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+		}
+		if key.where != "" {
+			// the predicate may contain OR, so it is wrapped in parentheses
+			params.methodParams.WhereAdditional = []string{"(" + key.where + ")"}
+		}
+
+		// the partial index of soft deleted tables usually already filters deleted rows
+		softDeleteColumn, err := getSoftDeleteColumn(params)
+		if err != nil {
+			return err
+		}
+		if !hasNullCondition(key.where, softDeleteColumn) {
+			if params, err = withSoftDeleteFilter(params); err != nil {
+				return err
+			}
+		}
+
+		lastIndex := 1
+		if err := s.processWhereParam(params, METHOD_GET, &lastIndex); err != nil {
+			return err
+		}
+		p.builder.WriteString(" LIMIT 1;\n\n")
+	}
+
+	return nil
+}
+
+func (s *crud) processFind(cfg config.CrudParams, p processParams) error {
+	p, err := withSoftDeleteFilter(p)
+	if err != nil {
+		return err
+	}
+
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_FIND, p.tableName())
+	}
+
+	order := getOrderByParams(p.methodParams)
+
+	// sqlc can not parse sqlc.arg in LIMIT and OFFSET of mysql
+	limit := p.methodParams.Limit || p.methodParams.Pagination == PAGINATION_KEYSET
+	if cfg.NamedParams && limit && !d.namedLimit {
+		return fmt.Errorf("named_params is not supported for limit of %s", p.engine)
+	}
+
+	var keysetColumns []string
+	switch p.methodParams.Pagination {
+	case "", PAGINATION_OFFSET:
+	case PAGINATION_KEYSET:
+		primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+		if err != nil {
+			return err
+		}
+
+		direction := "DESC"
+		if order != nil {
+			if !slices.Contains(p.metaData.columns, order.By) {
+				return fmt.Errorf("order column %s does not exist in table %s", order.By, p.table)
+			}
+			direction = strings.ToUpper(order.Direction)
+			keysetColumns = append(keysetColumns, order.By)
+		}
+
+		// primary key is used as a tie-breaker for rows with the same order value
+		for _, column := range primaryColumns {
+			if !slices.Contains(keysetColumns, column) {
+				keysetColumns = append(keysetColumns, column)
+			}
+		}
+
+		operator := "<"
+		if direction == "ASC" {
+			operator = ">"
+		}
+
+		// sqlite mixes up numbers of positional and named params, so all params are named
+		p.namedParams = true
+		p.methodParams.WhereAdditional = append(
+			slices.Clone(p.methodParams.WhereAdditional),
+			getKeysetCondition(d, keysetColumns, operator),
+		)
+
+		// each keyset column is sorted in the same direction. Example: created_at DESC, id DESC
+		orderColumns := make([]string, len(keysetColumns))
+		for i, column := range keysetColumns {
+			orderColumns[i] = d.ident(column)
+		}
+		order = &config.OrderParam{By: strings.Join(orderColumns, " "+direction+", "), Direction: direction}
+	default:
+		return fmt.Errorf("unsupported pagination %s", p.methodParams.Pagination)
+	}
+
+	selectColumns, err := getSelectColumns(p, d)
+	if err != nil {
+		return err
+	}
+
+	lock, err := getLockClause(p, d)
+	if err != nil {
+		return err
+	}
+
+	writeQueryName(p, methodName, "many")
+	p.builder.WriteString("SELECT " + selectColumns + " FROM ")
+	p.builder.WriteString(d.ident(p.table))
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_FIND, &lastIndex); err != nil {
+		return err
+	}
+	if order != nil {
+		orderBy := order.By
+		if slices.Contains(p.metaData.columns, orderBy) {
+			orderBy = d.ident(orderBy)
+		}
+		p.builder.WriteString(fmt.Sprintf(" ORDER BY %s %s", orderBy, order.Direction))
+	}
+
+	switch {
+	case len(keysetColumns) > 0:
+		p.builder.WriteString(d.limitOffset(true, false, lastIndex))
+	case p.methodParams.Limit:
+		p.builder.WriteString(d.limitOffset(useNamedParams(p), true, lastIndex))
+	}
+	p.builder.WriteString(lock + ";\n\n")
+	return nil
+}
+
+// getLockClause returns row locking clause for SELECT.
+// Example: FOR UPDATE SKIP LOCKED
+func getLockClause(p processParams, d dialect) (string, error) {
+	lock := p.methodParams.Lock
+	if lock == (config.LockParams{}) {
+		return "", nil
+	}
+
+	if !d.locking {
+		return "", fmt.Errorf("row locking is not supported by %s", p.engine)
+	}
+
+	var clause string
+	switch {
+	case lock.ForUpdate && lock.ForShare:
+		return "", fmt.Errorf("for_update and for_share can not be used together")
+	case lock.ForUpdate:
+		clause = " FOR UPDATE"
+	case lock.ForShare:
+		clause = " FOR SHARE"
+	default:
+		return "", fmt.Errorf("for_update or for_share is required for row locking")
+	}
+
+	switch {
+	case lock.SkipLocked && lock.NoWait:
+		return "", fmt.Errorf("skip_locked and nowait can not be used together")
+	case lock.SkipLocked:
+		clause += " SKIP LOCKED"
+	case lock.NoWait:
+		clause += " NOWAIT"
+	}
+
+	return clause, nil
+}
+
+// processDequeue generates method which atomically claims rows of the queue table.
+// Rows locked by other transactions are skipped, so workers do not wait for each other.
+// sqlite has no row locks, but it serializes writes, so the statement is still atomic
+// and workers wait for each other instead of skipping claimed rows.
+// Example: UPDATE jobs SET status='processing' WHERE id IN (SELECT id FROM jobs WHERE status='pending'
+// ORDER BY created_at ASC LIMIT sqlc.arg('limit') FOR UPDATE SKIP LOCKED) RETURNING *
+func (s *crud) processDequeue(cfg config.CrudParams, p processParams) error {
+	p, err := withSoftDeleteFilter(p)
+	if err != nil {
+		return err
+	}
+
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	if !d.returning {
+		return fmt.Errorf("dequeue method is not supported by %s", p.engine)
+	}
+
+	if len(p.methodParams.ColumnValues) == 0 {
+		return fmt.Errorf("column_values are required")
+	}
+
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return err
+	}
+
+	returning := p.methodParams.Returning
+	if returning == "" {
+		returning = "*"
+	}
+
+	// limit is always named, so all params are named
+	p.namedParams = true
+
+	writeQueryName(p, s.getManyMethodName(cfg, "Dequeue", p), "many")
+	p.builder.WriteString("UPDATE ")
+	p.builder.WriteString(d.ident(p.table))
+	p.builder.WriteString("\n\tSET ")
+
+	index := 0
+	for _, name := range p.metaData.columns {
+		value, ok := p.methodParams.ColumnValues[name]
+		if !ok {
+			continue
+		}
+
+		if index > 0 {
+			p.builder.WriteString(", ")
+		}
+		p.builder.WriteString(d.ident(name) + "=" + value)
+		index++
+	}
+
+	keys := d.idents(primaryColumns)
+	if len(primaryColumns) > 1 {
+		keys = "(" + keys + ")"
+	}
+
+	p.builder.WriteString("\n\tWHERE " + keys + " IN (\n\t\tSELECT " + d.idents(primaryColumns) + " FROM ")
+	p.builder.WriteString(d.ident(p.table))
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_FIND, &lastIndex); err != nil {
+		return err
+	}
+
+	if order := getOrderByParams(p.methodParams); order != nil {
+		if !slices.Contains(p.metaData.columns, order.By) {
+			return fmt.Errorf("order column %s does not exist in table %s", order.By, p.table)
+		}
+		p.builder.WriteString(fmt.Sprintf(" ORDER BY %s %s", d.ident(order.By), order.Direction))
+	}
+	p.builder.WriteString(d.limitOffset(true, false, lastIndex))
+
+	if d.locking {
+		p.builder.WriteString(" FOR UPDATE SKIP LOCKED")
+	}
+	p.builder.WriteString("\n\t)\n\tRETURNING " + returning + ";\n\n")
+
+	return nil
+}
+
+// processTree generates recursive methods of self-referencing table:
+// FindCategoryDescendants, FindCategoryAncestors and FindCategorySubtree limited by depth.
+// Rows are returned with depth column, depth of the requested row is 0.
+// Descendants and ancestors are not limited, so the data must be acyclic
+func (s *crud) processTree(cfg config.CrudParams, p processParams) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	softDeleteColumn, err := getSoftDeleteColumn(p)
+	if err != nil {
+		return err
+	}
+
+	parentColumn, refColumn, err := getParentColumn(p)
+	if err != nil {
+		return err
+	}
+
+	order := ""
+	if by := p.methodParams.Order.By; by != "" {
+		if !slices.Contains(p.metaData.columns, by) {
+			return fmt.Errorf("order column %s does not exist in table %s", by, p.table)
+		}
+
+		// siblings are ordered ascending by default
+		direction := p.methodParams.Order.Direction
+		if direction == "" {
+			direction = "ASC"
+		}
+		order = ", " + d.ident(by) + " " + direction
+	}
+
+	prefix := p.methodParams.Name
+	if prefix == "" {
+		prefix = formatMethodName(cfg, "Find", p.tableName(), false)
+	}
+
+	// the table has an alias in both branches, because schema qualified columns are not valid: billing.invoices.*
+	table := d.ident(p.table)
+	alias := "t"
+	cte := d.ident(strings.ReplaceAll(p.table, ".", "_") + "_tree")
+
+	// depth limit of the subtree is always named, so params of all methods are named
+	id := fmt.Sprintf("sqlc.arg('%s')", refColumn)
+
+	// deleted rows and their subtrees are skipped
+	var conditions []string
+	if softDeleteColumn != "" {
+		conditions = append(conditions, alias+"."+d.ident(softDeleteColumn)+" IS NULL")
+	}
+
+	// writeQuery writes recursive query from the requested row.
+	// Join condition is the direction of the recursion, limit stops the recursion
+	writeQuery := func(name, join, limit, filter string) {
+		anchor := append([]string{alias + "." + d.ident(refColumn) + "=" + id}, conditions...)
+		recursive := slices.Clone(conditions)
+		if limit != "" {
+			recursive = append(recursive, limit)
+		}
+
+		writeQueryName(p, name, "many")
+		p.builder.WriteString("WITH RECURSIVE " + cte + " AS (\n")
+		p.builder.WriteString("\tSELECT " + alias + ".*, 0 AS depth FROM " + table + " " + alias + "\n")
+		p.builder.WriteString("\t\tWHERE " + strings.Join(anchor, " AND ") + "\n")
+		p.builder.WriteString("\tUNION ALL\n")
+		p.builder.WriteString("\tSELECT " + alias + ".*, " + cte + ".depth+1 FROM " + table + " " + alias + "\n")
+		p.builder.WriteString("\t\tJOIN " + cte + " ON " + join + "\n")
+		if len(recursive) > 0 {
+			p.builder.WriteString("\t\tWHERE " + strings.Join(recursive, " AND ") + "\n")
+		}
+		p.builder.WriteString(")\n")
+		p.builder.WriteString("SELECT * FROM " + cte + filter + " ORDER BY depth" + order + ";\n\n")
+	}
+
+	children := alias + "." + d.ident(parentColumn) + "=" + cte + "." + d.ident(refColumn)
+	parents := alias + "." + d.ident(refColumn) + "=" + cte + "." + d.ident(parentColumn)
+
+	writeQuery(prefix+"Descendants", children, "", " WHERE depth > 0")
+	writeQuery(prefix+"Ancestors", parents, "", " WHERE depth > 0")
+
+	limit := cte + ".depth < " + d.integer("sqlc.arg('max_depth')")
+	writeQuery(prefix+"Subtree", children, limit, "")
+
+	return nil
+}
+
+// getParentColumn returns the column which references the parent row and the referenced column
+func getParentColumn(p processParams) (string, string, error) {
+	parentColumn := p.methodParams.ParentColumn
+	for _, fk := range p.metaData.foreignKeys {
+		if fk.refTable != p.table || len(fk.columns) != 1 {
+			continue
+		}
+		if parentColumn != "" && fk.columns[0] != parentColumn {
+			continue
+		}
+
+		if len(fk.refColumns) == 1 {
+			return fk.columns[0], fk.refColumns[0], nil
+		}
+		parentColumn = fk.columns[0]
+		break
+	}
+
+	if parentColumn == "" {
+		return "", "", fmt.Errorf("parent_column is required if the table does not reference itself")
+	}
+	if !slices.Contains(p.metaData.columns, parentColumn) {
+		return "", "", fmt.Errorf("parent column %s does not exist in table %s", parentColumn, p.table)
+	}
+
+	// parent column references the primary key by default
+	primaryColumns, err := getPrimaryColumns(p.metaData, p.table, p.tableParams.PrimaryColumn)
+	if err != nil {
+		return "", "", err
+	}
+	if len(primaryColumns) != 1 {
+		return "", "", fmt.Errorf("tree method is not supported for composite primary key")
+	}
+
+	return parentColumn, primaryColumns[0], nil
+}
+
+// getSelectColumns returns columns for SELECT clause.
+// By default all columns are selected with *
+func getSelectColumns(p processParams, d dialect) (string, error) {
+	if len(p.methodParams.SelectColumns) == 0 && len(p.methodParams.ExcludeColumns) == 0 {
+		return "*", nil
+	}
+
+	for _, column := range slices.Concat(p.methodParams.SelectColumns, p.methodParams.ExcludeColumns) {
+		if !slices.Contains(p.metaData.columns, column) {
+			return "", fmt.Errorf("column %s does not exist in table %s", column, p.table)
+		}
+	}
+
+	columns := p.metaData.columns
+	if len(p.methodParams.SelectColumns) > 0 {
+		columns = p.methodParams.SelectColumns
+	}
+
+	columns = cmnutils.FilterValues(columns, p.methodParams.ExcludeColumns)
+	if len(columns) == 0 {
+		return "", fmt.Errorf("no columns to select from table %s", p.table)
+	}
+
+	return d.idents(columns), nil
+}
+
+// getKeysetCondition returns condition for the rows after the cursor.
+// Example: (created_at < cursor_created_at OR (created_at = cursor_created_at AND id < cursor_id))
+//
+// Row values comparison is not used, because sqlc infers the type of each param from the first column
+func getKeysetCondition(d dialect, columns []string, operator string) string {
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		parts := make([]string, 0, i+1)
+		for _, prev := range columns[:i] {
+			parts = append(parts, fmt.Sprintf("%s = sqlc.arg('cursor_%s')", d.ident(prev), prev))
+		}
+		parts = append(parts, fmt.Sprintf("%s %s sqlc.arg('cursor_%s')", d.ident(column), operator, column))
+
+		conditions[i] = strings.Join(parts, " AND ")
+		if i > 0 {
+			conditions[i] = "(" + conditions[i] + ")"
+		}
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// processRelation generates the method for each foreign key of the table
+func (s *crud) processRelation(cfg config.CrudParams, methodType config.MethodType, p processParams) error {
+	for _, fk := range p.metaData.foreignKeys {
+		columns := fk.columns
+		params := p
+		params.methodParams.Where = maps.Clone(p.methodParams.Where)
+		for _, column := range columns {
+			params.methodParams.AddWhereParam(column, config.WhereParamsItem{})
+		}
+
+		// relation methods return or delete many rows, so the table name is in plural form
+		suffix := "By" + getColumnsMethodSuffix(columns)
+
+		var err error
+		switch methodType {
+		case METHOD_FIND_BY:
+			params.methodParams.Name = formatMethodName(cfg, "Find", p.tableName(), true) + suffix
+			err = s.processFind(cfg, params)
+		case METHOD_TOTAL_BY:
+			params.methodParams.Name = formatMethodName(cfg, "Total", p.tableName(), true) + suffix
+			err = s.processTotal(cfg, params)
+		case METHOD_DELETE_BY:
+			params.methodParams.Name = formatMethodName(cfg, "Delete", p.tableName(), true) + suffix
+			err = s.processDeleteBy(params)
+		default:
+			return fmt.Errorf("unsupported relation method %s", methodType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *crud) processDeleteBy(p processParams) error {
+	softDeleteColumn, err := getSoftDeleteColumn(p)
+	if err != nil {
+		return err
+	}
+
+	writeQueryName(p, p.methodParams.Name, "exec")
+
+	return s.processDeleteStatement(p, softDeleteColumn)
+}
+
+// processManyToMany generates methods of the link table of many-to-many relation:
+// attach and detach the related row, find rows of both tables through the link table
+// and delete all links of the owner row
+func (s *crud) processManyToMany(cfg config.CrudParams, p processParams, data tables, tablesParams config.Table) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	params := p.tableParams.ManyToMany
+	if params.Column == "" || params.RelatedColumn == "" {
+		return fmt.Errorf("column and related_column are required")
+	}
+	if params.Column == params.RelatedColumn {
+		return fmt.Errorf("column and related_column must be different")
+	}
+
+	owner, err := getManyToManySide(p, data, tablesParams, params.Column)
+	if err != nil {
+		return err
+	}
+
+	related, err := getManyToManySide(p, data, tablesParams, params.RelatedColumn)
+	if err != nil {
+		return err
+	}
+
+	ownerSingular, _ := inflect(owner.name, cfg.IrregularWords)
+	relatedSingular, relatedPlural := inflect(related.name, cfg.IrregularWords)
+	ownerSingular = stringy.New(ownerSingular).CamelCase().UcFirst()
+	relatedSingular = stringy.New(relatedSingular).CamelCase().UcFirst()
+	relatedPlural = stringy.New(relatedPlural).CamelCase().UcFirst()
+
+	p.methodParams = config.Method{}
+	named := useNamedParams(p)
+
+	// attach
+	values := []string{d.placeholder(1), d.placeholder(2)}
+	if named {
+		values = []string{
+			fmt.Sprintf("sqlc.arg('%s')", owner.column),
+			fmt.Sprintf("sqlc.arg('%s')", related.column),
+		}
+	}
+
+	writeQueryName(p, formatMethodName(cfg, "Attach", related.name, false)+"To"+ownerSingular, "exec")
+	p.builder.WriteString("INSERT INTO " + d.ident(p.table) + " (" + d.idents([]string{owner.column, related.column}) + ")")
+	p.builder.WriteString("\n\tVALUES (" + strings.Join(values, ", ") + ")")
+
+	// already attached row is kept
+	if d.onDuplicateKey {
+		p.builder.WriteString("\n\tON DUPLICATE KEY UPDATE " + d.ident(owner.column) + "=" + d.ident(owner.column))
+	} else {
+		p.builder.WriteString("\n\tON CONFLICT DO NOTHING")
+	}
+	p.builder.WriteString(";\n\n")
+
+	// detach. Links are deleted even if soft delete is enabled for the link table
+	detachParams := p
+	detachParams.methodParams = config.Method{}
+	detachParams.methodParams.AddWhereParam(owner.column, config.WhereParamsItem{})
+	detachParams.methodParams.AddWhereParam(related.column, config.WhereParamsItem{})
+
+	writeQueryName(detachParams, formatMethodName(cfg, "Detach", related.name, false)+"From"+ownerSingular, "exec")
+	if err := s.processDeleteStatement(detachParams, ""); err != nil {
+		return err
+	}
+
+	// find rows of both tables
+	writeManyToManyFind(p, d, named, formatMethodName(cfg, "Find", related.name, true)+"By"+ownerSingular, related, owner)
+	writeManyToManyFind(p, d, named, formatMethodName(cfg, "Find", owner.name, true)+"By"+relatedSingular, owner, related)
+
+	// delete all links of the owner row, so the set of related rows can be replaced
+	deleteParams := p
+	deleteParams.methodParams = config.Method{}
+	deleteParams.methodParams.AddWhereParam(owner.column, config.WhereParamsItem{})
+
+	writeQueryName(deleteParams, formatMethodName(cfg, "Delete", owner.name, false)+relatedPlural, "exec")
+
+	return s.processDeleteStatement(deleteParams, "")
+}
+
+// getManyToManySide returns the table referenced by the foreign key column of the link table
+func getManyToManySide(p processParams, data tables, tablesParams config.Table, column string) (manyToManySide, error) {
+	for _, fk := range p.metaData.foreignKeys {
+		if !slices.Equal(fk.columns, []string{column}) {
+			continue
+		}
+
+		metaData := data.getTableMetaData(fk.refTable)
+		if metaData == nil {
+			return manyToManySide{}, fmt.Errorf("database does not exist table: %s", fk.refTable)
+		}
+
+		// foreign key references the primary key if columns are omitted
+		refColumns := fk.refColumns
+		if len(refColumns) == 0 {
+			refColumns = metaData.primaryColumns
+		}
+		if len(refColumns) != 1 {
+			return manyToManySide{}, fmt.Errorf("undefined referenced column of %s", column)
+		}
+
+		return manyToManySide{
+			column:           column,
+			table:            fk.refTable,
+			refColumn:        refColumns[0],
+			name:             metaData.name,
+			softDeleteColumn: tablesParams[fk.refTable].SoftDelete.Column,
+		}, nil
+	}
+
+	return manyToManySide{}, fmt.Errorf("column %s is not a single column foreign key of table %s", column, p.table)
+}
+
+// writeManyToManyFind writes the query which selects rows of the target table
+// through the link table by the foreign key of the filter table
+func writeManyToManyFind(p processParams, d dialect, named bool, name string, target, filter manyToManySide) {
+	value := d.placeholder(1)
+	if named {
+		value = fmt.Sprintf("sqlc.arg('%s')", filter.column)
+	}
+
+	table, link := d.ident(target.table), d.ident(p.table)
+
+	writeQueryName(p, name, "many")
+	p.builder.WriteString("SELECT " + table + ".* FROM " + table)
+	p.builder.WriteString("\n\tJOIN " + link + " ON " + link + "." + d.ident(target.column) + "=" + table + "." + d.ident(target.refColumn))
+	p.builder.WriteString("\n\tWHERE " + link + "." + d.ident(filter.column) + "=" + value)
+	if target.softDeleteColumn != "" {
+		p.builder.WriteString(" AND " + table + "." + d.ident(target.softDeleteColumn) + " IS NULL")
+	}
+	p.builder.WriteString(";\n\n")
+}
+
+func (s *crud) processTotal(cfg config.CrudParams, p processParams) error {
+	p, err := withSoftDeleteFilter(p)
+	if err != nil {
+		return err
+	}
+
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	methodName := p.methodParams.Name
+	if methodName == "" {
+		methodName = s.getMethodName(cfg, METHOD_TOTAL, p.tableName())
+	}
+
+	writeQueryName(p, methodName, "one")
+	p.builder.WriteString("SELECT count(1) as total FROM ")
+	p.builder.WriteString(d.ident(p.table))
+	lastIndex := 1
+	if err := s.processWhereParam(p, METHOD_TOTAL, &lastIndex); err != nil {
 		return err
 	}
 	p.builder.WriteString(";\n\n")
@@ -518,24 +2238,51 @@ func (s *crud) processTotal(cfg config.CrudParams, p processParams) error {
 }
 
 func (s *crud) processExists(cfg config.CrudParams, p processParams) error {
+	p, err := withSoftDeleteFilter(p)
+	if err != nil {
+		return err
+	}
+
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
 	methodName := p.methodParams.Name
 	if methodName == "" {
-		methodName = s.getMethodName(cfg, METHOD_EXISTS, p.table)
+		methodName = s.getMethodName(cfg, METHOD_EXISTS, p.tableName())
 	}
 
-	p.builder.WriteString(fmt.Sprintf("-- name: %s :one\n", methodName))
-	p.builder.WriteString("SELECT EXISTS (SELECT 1 FROM ")
-	p.builder.WriteString(p.table)
+	// subquery is written separately to be casted to boolean
+	builder := p.builder
+	p.builder = new(strings.Builder)
+	p.builder.WriteString("EXISTS (SELECT 1 FROM ")
+	p.builder.WriteString(d.ident(p.table))
 	lastIndex := 1
 	if err := s.processWhereParam(p, METHOD_EXISTS, &lastIndex); err != nil {
 		return err
 	}
-	p.builder.WriteString(" LIMIT 1)::boolean;\n\n")
+	p.builder.WriteString(" LIMIT 1)")
+
+	subquery := p.builder.String()
+	p.builder = builder
+	writeQueryName(p, methodName, "one")
+	p.builder.WriteString("SELECT " + d.boolean(subquery) + ";\n\n")
 
 	return nil
 }
 
 func (s *crud) processWhereParam(p processParams, method config.MethodType, lastIndex *int) error {
+	d, err := p.dialect()
+	if err != nil {
+		return err
+	}
+
+	named := useNamedParams(p)
+	if named && !d.mixedParams && *lastIndex > 1 {
+		return fmt.Errorf("named where params can not be combined with positional params in %s", p.engine)
+	}
+
 	// process where params
 	if params := getWhereParams(p.methodParams, method); len(params) > 0 {
 		// Sort params
@@ -565,29 +2312,13 @@ func (s *crud) processWhereParam(p processParams, method config.MethodType, last
 			}
 
 			if item.Value == "" {
-				operator := item.Operator
-				if operator == "" {
-					operator = "="
-				}
-
-				switch p.engine {
-				case EngineTypePostgres:
-					_, err := fmt.Fprintf(p.builder, "%s%s$%d", param, operator, *lastIndex)
-					if err != nil {
-						return err
-					}
-				case EngineTypeMysql, EngineTypeSqlite:
-					_, err := fmt.Fprintf(p.builder, "%s%s?", param, operator)
-					if err != nil {
-						return err
-					}
-				default:
-					return fmt.Errorf("engine %s is not supported", p.engine)
+				predicate, err := getWherePredicate(p, d, param, item, named, lastIndex)
+				if err != nil {
+					return err
 				}
-
-				*lastIndex++
+				p.builder.WriteString(predicate)
 			} else {
-				p.builder.WriteString(param)
+				p.builder.WriteString(d.ident(param))
 				if item.Operator != "" {
 					p.builder.WriteString(fmt.Sprintf(" %s", item.Operator))
 				}
@@ -624,24 +2355,217 @@ func (s *crud) processWhereParam(p processParams, method config.MethodType, last
 	return nil
 }
 
-func (s *crud) saveFile(cfg config.PgxgenSqlc, data []byte, tableName, path string) error {
-	tableParams, ok := cfg.CrudParams.Tables[tableName]
-	if !ok {
-		return fmt.Errorf("can not find table params for table: %s", tableName)
+// getWherePredicate returns condition for where param.
+// Optional params are skipped if null value is passed
+func getWherePredicate(p processParams, d dialect, name string, item config.WhereParamsItem, named bool, lastIndex *int) (string, error) {
+	operator := strings.ToLower(strings.TrimSpace(item.Operator))
+	column := d.ident(name)
+
+	param := func(name string) string {
+		switch {
+		case named && item.Optional:
+			return fmt.Sprintf("sqlc.narg('%s')", name)
+		case named:
+			return fmt.Sprintf("sqlc.arg('%s')", name)
+		}
+		*lastIndex++
+		return d.placeholder(*lastIndex - 1)
 	}
 
-	if tableParams.OutputDir != "" && tableParams.OutputDir != path {
-		return nil
+	optional := func(predicate, value string) string {
+		if !item.Optional {
+			return predicate
+		}
+		return "(" + predicate + " OR " + value + " IS NULL)"
+	}
+
+	switch operator {
+	case WHERE_OPERATOR_IN:
+		if !d.arrays {
+			if item.Optional {
+				return "", fmt.Errorf("optional %s operator is not supported by %s", operator, p.engine)
+			}
+			return fmt.Sprintf("%s IN (sqlc.slice('%s'))", column, name), nil
+		}
+
+		columnType, ok := p.metaData.columnTypes[name]
+		if !ok {
+			return "", fmt.Errorf("undefined type of column %s", name)
+		}
+
+		value := param(name)
+
+		return optional(fmt.Sprintf("%s = ANY(%s::%s[])", column, value, columnType), value), nil
+	case WHERE_OPERATOR_BETWEEN:
+		from := param(name + "_from")
+		to := param(name + "_to")
+
+		if !item.Optional {
+			return fmt.Sprintf("%s BETWEEN %s AND %s", column, from, to), nil
+		}
+
+		return fmt.Sprintf("(%s AND %s)",
+			optional(fmt.Sprintf("%s >= %s", column, from), from),
+			optional(fmt.Sprintf("%s <= %s", column, to), to),
+		), nil
+	}
+
+	switch operator {
+	case "":
+		operator = "="
+	case WHERE_OPERATOR_ILIKE:
+		// LIKE is case insensitive in mysql and sqlite by default
+		if d.ilike {
+			operator = " ILIKE "
+		} else {
+			operator = " LIKE "
+		}
+	default:
+		if value, ok := whereOperators[operator]; ok {
+			operator = value
+		} else {
+			operator = item.Operator
+		}
+	}
+
+	value := param(name)
+
+	return optional(column+operator+value, value), nil
+}
+
+// useNamedParams reports whether where params should be rendered as sqlc.arg
+func useNamedParams(p processParams) bool {
+	if p.namedParams {
+		return true
+	}
+
+	for _, item := range p.methodParams.Where {
+		if item.Value != "" {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(item.Operator)) {
+		case WHERE_OPERATOR_IN, WHERE_OPERATOR_BETWEEN:
+			return true
+		}
+
+		if item.Optional {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getFiles returns generated files by path relative to pgxgen config.
+// Queries are grouped by the layout and each file ends with the generated footer
+func (s *crud) getFiles(cfg config.CrudParams, sqlData map[string][]generatedQueries, tablesParams config.Table, queriesPaths []string, defaultSchema string) (map[string][]byte, error) {
+	layout := cfg.Layout
+	if layout == "" {
+		layout = LAYOUT_TABLE
+	}
+
+	nameTemplate := cfg.FileNameTemplate
+	if nameTemplate == "" {
+		switch layout {
+		case LAYOUT_TABLE:
+			nameTemplate = "{{.Table}}_gen.sql"
+		case LAYOUT_METHOD:
+			nameTemplate = "{{.Table}}_{{.Method}}_gen.sql"
+		case LAYOUT_SCHEMA:
+			nameTemplate = "{{.Schema}}_gen.sql"
+		default:
+			return nil, fmt.Errorf("unsupported layout %s", layout)
+		}
+	}
+
+	tmpl, err := template.New("file_name").Parse(nameTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("file_name_template error: %w", err)
+	}
+
+	// Sort tables
+	tableNames := make([]string, 0, len(sqlData))
+	for k := range sqlData {
+		tableNames = append(tableNames, k)
+	}
+	sort.Strings(tableNames)
+
+	footer := fmt.Sprintf("%s\n-- versions:\n--   pgxgen %s\n", generatedMarker, s.config.Pgxgen.Version)
+
+	files := make(map[string][]byte)
+	// tables of each file. Only schema layout combines tables in one file
+	fileTables := make(map[string]string)
+	for _, tableName := range tableNames {
+		tableParams, ok := tablesParams[tableName]
+		if !ok {
+			return nil, fmt.Errorf("can not find table params for table: %s", tableName)
+		}
+
+		paths := queriesPaths
+		if tableParams.OutputDir != "" {
+			paths = []string{tableParams.OutputDir}
+		}
+
+		data := fileNameData{
+			Schema: defaultSchema,
+			Table:  strings.ReplaceAll(tableName, ".", "_"),
+		}
+		if schema, _, ok := strings.Cut(tableName, "."); ok {
+			data.Schema = schema
+		}
+
+		chunks := sqlData[tableName]
+		if layout != LAYOUT_METHOD {
+			var content []byte
+			for _, item := range chunks {
+				content = append(content, item.data...)
+			}
+			chunks = []generatedQueries{{data: content}}
+		}
+
+		for _, chunk := range chunks {
+			if len(chunk.data) == 0 {
+				continue
+			}
+
+			data.Method = chunk.method
+
+			var fileName strings.Builder
+			if err := tmpl.Execute(&fileName, data); err != nil {
+				return nil, fmt.Errorf("file_name_template error: %w", err)
+			}
+
+			if fileName.Len() == 0 || strings.ContainsAny(fileName.String(), `/\`) {
+				return nil, fmt.Errorf("invalid file name %q for table %s", fileName.String(), tableName)
+			}
+
+			for _, p := range paths {
+				filePath := filepath.Join(p, fileName.String())
+
+				if _, ok := files[filePath]; !ok {
+					files[filePath] = nil
+					fileTables[filePath] = tableName
+				} else if layout != LAYOUT_SCHEMA || fileTables[filePath] == tableName {
+					return nil, fmt.Errorf("file %s is generated for table %s and %s", filePath, fileTables[filePath], tableName)
+				}
+
+				files[filePath] = append(files[filePath], chunk.data...)
+			}
+		}
 	}
 
-	if tableName == "" {
-		return fmt.Errorf("empty table name")
+	for filePath, data := range files {
+		files[filePath] = append(data, footer...)
 	}
 
-	outputDir := filepath.Join(s.pgxgenFileDir, path)
+	return files, nil
+}
+
+func (s *crud) saveFile(filePath string, data []byte) error {
+	outputDir := filepath.Join(s.pgxgenFileDir, filepath.Dir(filePath))
 
-	fileName := fmt.Sprintf("%s_gen.sql", tableName)
-	if err := utils.SaveFile(outputDir, fileName, data); err != nil {
+	if err := utils.SaveFile(outputDir, filepath.Base(filePath), data); err != nil {
 		return fmt.Errorf("SaveFile error: %w", err)
 	}
 
@@ -654,34 +2578,292 @@ func (s *crud) saveFile(cfg config.PgxgenSqlc, data []byte, tableName, path stri
 // }
 
 func (s *crud) getMethodName(cfg config.CrudParams, methodType config.MethodType, tableName string) string {
-	var methodName string
-	if cfg.ExcludeTableNameFromMethods {
-		methodName = methodType.String()
-	} else {
-		methodName = fmt.Sprintf("%s %s", methodType.String(), tableName)
+	many := slices.Contains([]config.MethodType{METHOD_FIND, METHOD_TOTAL, METHOD_CREATE_BULK}, methodType)
+
+	return formatMethodName(cfg, stringy.New(methodType.String()).CamelCase().UcFirst(), tableName, many)
+}
+
+// methodNameData is data of method_name_template
+type methodNameData struct {
+	// Method in camel case. Example: Get, FindBy
+	Method string
+	// Table name in camel case
+	Table    string
+	Singular string
+	Plural   string
+}
+
+// formatMethodName returns method name with the table name in singular form.
+// Methods which return or change many rows keep the table name as is.
+// Example: GetUser, FindUsers
+func formatMethodName(cfg config.CrudParams, method, tableName string, many bool) string {
+	singular, plural := inflect(tableName, cfg.IrregularWords)
+	data := methodNameData{
+		Method:   method,
+		Table:    stringy.New(tableName).CamelCase().UcFirst(),
+		Singular: stringy.New(singular).CamelCase().UcFirst(),
+		Plural:   stringy.New(plural).CamelCase().UcFirst(),
+	}
+
+	if cfg.MethodNameTemplate != "" {
+		// template is checked before generation
+		if name, err := executeMethodNameTemplate(cfg.MethodNameTemplate, data); err == nil {
+			return name
+		}
+	}
+
+	switch {
+	case cfg.ExcludeTableNameFromMethods:
+		return data.Method
+	case many:
+		return data.Method + data.Table
+	}
+
+	return data.Method + data.Singular
+}
+
+// checkMethodNameTemplate reports whether method_name_template can be executed
+func checkMethodNameTemplate(cfg config.CrudParams) error {
+	if cfg.MethodNameTemplate == "" {
+		return nil
+	}
+
+	name, err := executeMethodNameTemplate(cfg.MethodNameTemplate, methodNameData{
+		Method:   "Get",
+		Table:    "Users",
+		Singular: "User",
+		Plural:   "Users",
+	})
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		return fmt.Errorf("empty method name")
+	}
+
+	return nil
+}
+
+func executeMethodNameTemplate(text string, data methodNameData) (string, error) {
+	tmpl, err := template.New("method_name").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var res strings.Builder
+	if err := tmpl.Execute(&res, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(res.String()), nil
+}
+
+// uncountableWords are table names which inflection rules change by mistake. Example: data -> datum
+var uncountableWords = []string{"data"}
+
+// inflect returns singular and plural forms of the table name.
+// Irregular words are checked for the last word of the name before inflection rules
+func inflect(name string, irregularWords map[string]string) (string, string) {
+	prefix, word := "", name
+	if index := strings.LastIndex(name, "_"); index >= 0 {
+		prefix, word = name[:index+1], name[index+1:]
+	}
+
+	// words are matched in sorted order, because irregular words may overlap. Example: person: people, people: peoples
+	for _, singular := range slices.Sorted(maps.Keys(irregularWords)) {
+		plural := irregularWords[singular]
+		if strings.EqualFold(word, singular) || strings.EqualFold(word, plural) {
+			return prefix + singular, prefix + plural
+		}
+	}
+
+	if slices.ContainsFunc(uncountableWords, func(w string) bool { return strings.EqualFold(word, w) }) {
+		return name, name
+	}
+
+	singular := inflection.Singular(inflection.SingularParams{Name: name})
+
+	return singular, inflection.Plural(singular)
+}
+
+// getVersionColumn returns optimistic locking column of the table or empty string
+func getVersionColumn(p processParams) (string, error) {
+	column := p.tableParams.VersionColumn
+	if column != "" && !slices.Contains(p.metaData.columns, column) {
+		return "", fmt.Errorf("version column %s does not exist in table %s", column, p.table)
+	}
+
+	return column, nil
+}
+
+// getSoftDeleteColumn returns soft delete column of the table or empty string
+func getSoftDeleteColumn(p processParams) (string, error) {
+	column := p.tableParams.SoftDelete.Column
+	if column != "" && !slices.Contains(p.metaData.columns, column) {
+		return "", fmt.Errorf("soft delete column %s does not exist in table %s", column, p.table)
+	}
+
+	return column, nil
+}
+
+// checkReturning returns error if returning columns are set,
+// but the engine does not support RETURNING clause
+func checkReturning(p processParams, d dialect) error {
+	if p.methodParams.Returning != "" && !d.returning {
+		return fmt.Errorf("returning is not supported by %s", p.engine)
+	}
+
+	return nil
+}
+
+// getSoftDeleteValue returns value for soft delete column
+func getSoftDeleteValue(p processParams, d dialect) string {
+	if p.tableParams.SoftDelete.Value != "" {
+		return p.tableParams.SoftDelete.Value
+	}
+
+	return d.currentTimestamp
+}
+
+// withSoftDeleteFilter excludes soft deleted rows,
+// unless where params already contain soft delete column
+func withSoftDeleteFilter(p processParams) (processParams, error) {
+	column, err := getSoftDeleteColumn(p)
+	if err != nil || column == "" {
+		return p, err
+	}
+
+	if _, ok := p.methodParams.Where[column]; ok {
+		return p, nil
+	}
+
+	p.methodParams.Where = maps.Clone(p.methodParams.Where)
+	p.methodParams.AddWhereParam(column, config.WhereParamsItem{Value: "IS NULL"})
+
+	return p, nil
+}
+
+// serialTypes are pseudo types of auto incremented columns with their underlying types
+var serialTypes = map[string]string{
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+}
+
+// hasNullCondition reports whether the predicate requires the column to be null.
+// Example: deleted_at IS NULL AND status = 'active'
+func hasNullCondition(predicate, column string) bool {
+	if predicate == "" || column == "" {
+		return false
 	}
 
-	methodName = stringy.New(methodName).CamelCase().UcFirst()
+	predicate = strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(predicate, `"`, "")), " "))
+	if strings.Contains(predicate, " or ") {
+		return false
+	}
 
-	if !slices.Contains([]config.MethodType{METHOD_FIND, METHOD_TOTAL}, methodType) {
-		if strings.HasSuffix(methodName, "s") {
-			methodName = string(methodName[:len(methodName)-1])
+	for _, item := range strings.Split(predicate, " and ") {
+		if strings.Trim(item, "() ") == strings.ToLower(column)+" is null" {
+			return true
 		}
 	}
 
-	return methodName
+	return false
+}
+
+// getColumnType returns column type name which can be used in type cast
+func getColumnType(column *catalog.Column, defaultSchema string) string {
+	name := column.Type.Name
+	// serial types are not real types and can not be used in casts
+	if serial, ok := serialTypes[name]; ok {
+		name = serial
+	}
+	if column.Type.Schema != "" && column.Type.Schema != "pg_catalog" && column.Type.Schema != defaultSchema {
+		name = column.Type.Schema + "." + name
+	}
+	if column.IsArray {
+		name += "[]"
+	}
+	return name
+}
+
+// getColumnsMethodSuffix returns columns in camel case joined by And.
+// Example: org_id, login -> OrgIDAndLogin
+func getColumnsMethodSuffix(columns []string) string {
+	res := make([]string, len(columns))
+	for i, column := range columns {
+		for _, part := range strings.Split(column, "_") {
+			if part == "id" {
+				res[i] += "ID"
+				continue
+			}
+			res[i] += stringy.New(part).UcFirst()
+		}
+	}
+	return strings.Join(res, "And")
 }
 
-func getPrimaryColumn(columns []string, table, column string) (string, error) {
-	primaryColumn := ""
+// getPrimaryColumns returns the configured primary column
+// or the primary key columns declared in the schema
+func getPrimaryColumns(metaData tableMetaData, table, column string) ([]string, error) {
 	if column != "" {
-		if !slices.Contains(columns, column) {
-			return primaryColumn, fmt.Errorf("table %s does not have a primary column %s", table, column)
+		if !slices.Contains(metaData.columns, column) {
+			return nil, fmt.Errorf("table %s does not have a primary column %s", table, column)
+		}
+		return []string{column}, nil
+	}
+
+	if len(metaData.primaryColumns) == 0 {
+		return nil, ErrUndefinedPrimaryColumn
+	}
+
+	return metaData.primaryColumns, nil
+}
+
+// getUniqueKeys returns unique constraints and unique indexes of the table.
+// Keys equal to the primary key, expression indexes and duplicates are skipped
+func getUniqueKeys(table *catalog.Table, columns []string) []uniqueKey {
+	keys := make([]uniqueKey, 0, len(table.UniqueKeys))
+	for _, key := range table.UniqueKeys {
+		keys = append(keys, uniqueKey{columns: key})
+	}
+	for _, index := range table.Indexes {
+		if index.Unique {
+			keys = append(keys, uniqueKey{columns: index.Columns, where: index.Where})
+		}
+	}
+
+	sameColumns := func(a, b []string) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for _, c := range a {
+			if !slices.Contains(b, c) {
+				return false
+			}
+		}
+		return true
+	}
+
+	res := make([]uniqueKey, 0, len(keys))
+	for _, key := range keys {
+		if len(key.columns) == 0 || sameColumns(key.columns, table.PrimaryKey) {
+			continue
+		}
+		if slices.ContainsFunc(key.columns, func(c string) bool { return !slices.Contains(columns, c) }) {
+			continue
+		}
+		if slices.ContainsFunc(res, func(k uniqueKey) bool { return sameColumns(k.columns, key.columns) }) {
+			continue
 		}
-		primaryColumn = column
+		res = append(res, key)
 	}
 
-	return primaryColumn, nil
+	return res
 }
 
 func getWhereParams(method config.Method, methodType config.MethodType) map[string]config.WhereParamsItem {
@@ -0,0 +1,2273 @@
+package crud
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/tkcrm/pgxgen/internal/config"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/cmd"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/compiler"
+	sqlcconfig "github.com/tkcrm/pgxgen/pkg/sqlc/config"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/opts"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/ast"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/catalog"
+	_ "modernc.org/sqlite"
+)
+
+type processFunc func(s *crud, cfg config.CrudParams, p processParams) error
+
+func runProcess(t *testing.T, fn processFunc, cfg config.CrudParams, p processParams) string {
+	t.Helper()
+
+	p.builder = new(strings.Builder)
+	if p.engine == "" {
+		p.engine = EngineTypePostgres
+	}
+
+	if err := fn(&crud{}, cfg, p); err != nil {
+		t.Fatal(err)
+	}
+
+	return p.builder.String()
+}
+
+func TestPrimaryColumns(t *testing.T) {
+	books := tableMetaData{
+		columns:        []string{"book_id", "author_id", "position"},
+		primaryColumns: []string{"book_id", "author_id"},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		fn          processFunc
+		metaData    tableMetaData
+		tableParams config.TableParams
+		method      config.Method
+		expected    string
+	}{
+		{
+			name:     "get by composite key",
+			fn:       (*crud).processGet,
+			metaData: books,
+			expected: "-- name: GetBookAuthor :one\nSELECT * FROM book_authors WHERE author_id=$1 AND book_id=$2 LIMIT 1;\n\n",
+		},
+		{
+			name:     "update by composite key",
+			fn:       (*crud).processUpdate,
+			metaData: books,
+			method: config.Method{
+				SkipColumns: []string{"book_id", "author_id"},
+			},
+			expected: "-- name: UpdateBookAuthor :exec\nUPDATE book_authors\n\tSET position=$1\n\tWHERE author_id=$2 AND book_id=$3;\n\n",
+		},
+		{
+			name:     "delete by composite key",
+			fn:       (*crud).processDelete,
+			metaData: books,
+			expected: "-- name: DeleteBookAuthor :exec\nDELETE FROM book_authors WHERE author_id=$1 AND book_id=$2;\n\n",
+		},
+		{
+			name:        "configured primary column wins",
+			fn:          (*crud).processDelete,
+			metaData:    books,
+			tableParams: config.TableParams{PrimaryColumn: "position"},
+			expected:    "-- name: DeleteBookAuthor :exec\nDELETE FROM book_authors WHERE position=$1;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "book_authors",
+				metaData:     tc.metaData,
+				methodParams: tc.method,
+				tableParams:  tc.tableParams,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUndefinedPrimaryColumn(t *testing.T) {
+	err := (&crud{}).processGet(config.CrudParams{}, processParams{
+		builder:  new(strings.Builder),
+		table:    "logs",
+		metaData: tableMetaData{columns: []string{"message"}},
+		engine:   EngineTypePostgres,
+	})
+	if err != ErrUndefinedPrimaryColumn {
+		t.Fatalf("expected %v, got %v", ErrUndefinedPrimaryColumn, err)
+	}
+}
+
+func TestGetByUnique(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "email", "org_id", "login"},
+		primaryColumns: []string{"id"},
+		uniqueKeys: []uniqueKey{
+			{columns: []string{"email"}, where: "email <> ''"},
+			{columns: []string{"org_id", "login"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		engine   engineType
+		expected string
+	}{
+		{
+			name:   "postgresql",
+			engine: EngineTypePostgres,
+			expected: "-- name: GetAuthorByEmail :one\nSELECT * FROM authors WHERE email=$1 AND (email <> '') LIMIT 1;\n\n" +
+				"-- name: GetAuthorByOrgIDAndLogin :one\nSELECT * FROM authors WHERE login=$1 AND org_id=$2 LIMIT 1;\n\n",
+		},
+		{
+			name:   "mysql",
+			engine: EngineTypeMysql,
+			expected: "-- name: GetAuthorByEmail :one\nSELECT * FROM authors WHERE email=? AND (email <> '') LIMIT 1;\n\n" +
+				"-- name: GetAuthorByOrgIDAndLogin :one\nSELECT * FROM authors WHERE login=? AND org_id=? LIMIT 1;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, (*crud).processGetByUnique, config.CrudParams{}, processParams{
+				table:    "authors",
+				metaData: metaData,
+				engine:   tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetByUniqueSoftDelete(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "email", "login", "org_id", "deleted_at"},
+		primaryColumns: []string{"id"},
+		uniqueKeys: []uniqueKey{
+			{columns: []string{"email"}, where: "(deleted_at IS NULL)"},
+			{columns: []string{"login"}, where: "login <> '' AND deleted_at IS NULL"},
+			{columns: []string{"org_id"}, where: "deleted_at IS NULL OR org_id > 0"},
+		},
+	}
+
+	actual := runProcess(t, (*crud).processGetByUnique, config.CrudParams{}, processParams{
+		table:       "authors",
+		metaData:    metaData,
+		tableParams: config.TableParams{SoftDelete: config.SoftDeleteParams{Column: "deleted_at"}},
+	})
+
+	expected := "-- name: GetAuthorByEmail :one\nSELECT * FROM authors WHERE email=$1 AND ((deleted_at IS NULL)) LIMIT 1;\n\n" +
+		"-- name: GetAuthorByLogin :one\nSELECT * FROM authors WHERE login=$1 AND (login <> '' AND deleted_at IS NULL) LIMIT 1;\n\n" +
+		"-- name: GetAuthorByOrgID :one\nSELECT * FROM authors WHERE deleted_at IS NULL AND org_id=$1 AND (deleted_at IS NULL OR org_id > 0) LIMIT 1;\n\n"
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("sql mismatch:\n%s", diff)
+	}
+}
+
+func TestGetUniqueKeys(t *testing.T) {
+	table := &catalog.Table{
+		PrimaryKey: []string{"id"},
+		UniqueKeys: [][]string{{"id"}, {"email"}},
+		Indexes: []*catalog.Index{
+			{Name: "authors_email_idx", Columns: []string{"email"}, Unique: true},
+			{Name: "authors_login_idx", Columns: []string{"login"}, Unique: true, Where: "login <> ''"},
+			{Name: "authors_lower_login_idx", Columns: []string{"lower(login)"}, Unique: true},
+			{Name: "authors_org_idx", Columns: []string{"org_id"}},
+		},
+	}
+
+	expected := []uniqueKey{
+		{columns: []string{"email"}},
+		{columns: []string{"login"}, where: "login <> ''"},
+	}
+
+	actual := getUniqueKeys(table, []string{"id", "email", "login", "org_id"})
+	if diff := cmp.Diff(expected, actual, cmp.AllowUnexported(uniqueKey{})); diff != "" {
+		t.Errorf("unique keys mismatch:\n%s", diff)
+	}
+}
+
+func TestRelations(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "author_id", "name", "created_at"},
+		primaryColumns: []string{"id"},
+		foreignKeys:    []foreignKey{{columns: []string{"author_id"}}},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		methodType config.MethodType
+		method     config.Method
+		expected   string
+	}{
+		{
+			name:       "find by",
+			methodType: METHOD_FIND_BY,
+			method: config.Method{
+				Order: config.OrderParam{By: "created_at"},
+				Limit: true,
+			},
+			expected: "-- name: FindBooksByAuthorID :many\nSELECT * FROM books WHERE author_id=$1 ORDER BY created_at DESC LIMIT $2 OFFSET $3;\n\n",
+		},
+		{
+			name:       "total by",
+			methodType: METHOD_TOTAL_BY,
+			expected:   "-- name: TotalBooksByAuthorID :one\nSELECT count(1) as total FROM books WHERE author_id=$1;\n\n",
+		},
+		{
+			name:       "delete by",
+			methodType: METHOD_DELETE_BY,
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{"name": {}},
+			},
+			expected: "-- name: DeleteBooksByAuthorID :exec\nDELETE FROM books WHERE author_id=$1 AND name=$2;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := func(s *crud, cfg config.CrudParams, p processParams) error {
+				return s.processRelation(cfg, tc.methodType, p)
+			}
+
+			actual := runProcess(t, fn, config.CrudParams{}, processParams{
+				table:        "books",
+				metaData:     metaData,
+				methodParams: tc.method,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKeysetPagination(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "author_id", "created_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		engine   engineType
+		method   config.Method
+		expected string
+	}{
+		{
+			name:   "postgresql",
+			engine: EngineTypePostgres,
+			method: config.Method{
+				Where:      map[string]config.WhereParamsItem{"author_id": {}},
+				Order:      config.OrderParam{By: "created_at"},
+				Pagination: PAGINATION_KEYSET,
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books WHERE author_id=sqlc.arg('author_id') " +
+				"AND (created_at < sqlc.arg('cursor_created_at') OR (created_at = sqlc.arg('cursor_created_at') AND id < sqlc.arg('cursor_id'))) " +
+				"ORDER BY created_at DESC, id DESC LIMIT sqlc.arg('limit');\n\n",
+		},
+		{
+			name:   "mysql",
+			engine: EngineTypeMysql,
+			method: config.Method{
+				Order:      config.OrderParam{By: "created_at", Direction: "asc"},
+				Pagination: PAGINATION_KEYSET,
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books " +
+				"WHERE (created_at > sqlc.arg('cursor_created_at') OR (created_at = sqlc.arg('cursor_created_at') AND id > sqlc.arg('cursor_id'))) " +
+				"ORDER BY created_at ASC, id ASC LIMIT ?;\n\n",
+		},
+		{
+			name:   "sqlite without order",
+			engine: EngineTypeSqlite,
+			method: config.Method{
+				Pagination: PAGINATION_KEYSET,
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books WHERE (id < sqlc.arg('cursor_id')) ORDER BY id DESC LIMIT sqlc.arg('limit');\n\n",
+		},
+		{
+			name:   "offset",
+			engine: EngineTypeSqlite,
+			method: config.Method{
+				Limit: true,
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books LIMIT ? OFFSET ?;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, (*crud).processFind, config.CrudParams{}, processParams{
+				table:        "books",
+				metaData:     metaData,
+				methodParams: tc.method,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "email", "name", "created_at", "updated_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		engine   engineType
+		method   config.Method
+		expected string
+	}{
+		{
+			name:   "postgresql",
+			engine: EngineTypePostgres,
+			method: config.Method{
+				ConflictColumns: []string{"email"},
+				SkipColumns:     []string{"id"},
+				UpdateColumns:   []string{"name", "updated_at"},
+				ColumnValues:    map[string]string{"created_at": "now()", "updated_at": "now()"},
+				Returning:       "*",
+			},
+			expected: "-- name: UpsertAuthor :one\nINSERT INTO authors (email, name, created_at, updated_at)\n\tVALUES ($1, $2, now(), now())" +
+				"\n\tON CONFLICT (email) DO UPDATE\n\tSET name=EXCLUDED.name, updated_at=EXCLUDED.updated_at\n\tRETURNING *;\n\n",
+		},
+		{
+			name:   "sqlite by primary key",
+			engine: EngineTypeSqlite,
+			method: config.Method{
+				SkipColumns: []string{"created_at", "updated_at"},
+			},
+			expected: "-- name: UpsertAuthor :exec\nINSERT INTO authors (id, email, name)\n\tVALUES (?, ?, ?)" +
+				"\n\tON CONFLICT (id) DO UPDATE\n\tSET email=EXCLUDED.email, name=EXCLUDED.name;\n\n",
+		},
+		{
+			name:   "mysql",
+			engine: EngineTypeMysql,
+			method: config.Method{
+				SkipColumns: []string{"created_at", "updated_at"},
+			},
+			expected: "-- name: UpsertAuthor :exec\nINSERT INTO authors (id, email, name)\n\tVALUES (?, ?, ?)" +
+				"\n\tON DUPLICATE KEY UPDATE email=VALUES(email), name=VALUES(name);\n\n",
+		},
+		{
+			name:   "do nothing",
+			engine: EngineTypePostgres,
+			method: config.Method{
+				SkipColumns:     []string{"name", "created_at", "updated_at"},
+				ConflictColumns: []string{"id", "email"},
+			},
+			expected: "-- name: UpsertAuthor :exec\nINSERT INTO authors (id, email)\n\tVALUES ($1, $2)" +
+				"\n\tON CONFLICT (id, email) DO NOTHING;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, (*crud).processUpsert, config.CrudParams{}, processParams{
+				table:        "authors",
+				metaData:     metaData,
+				methodParams: tc.method,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBatchMethods(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "name", "created_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		method   config.Method
+		expected string
+	}{
+		{
+			name:     "create bulk",
+			fn:       (*crud).processCreateBulk,
+			method:   config.Method{SkipColumns: []string{"id"}},
+			expected: "-- name: CreateBulkAuthors :copyfrom\nINSERT INTO authors (name, created_at)\n\tVALUES ($1, $2);\n\n",
+		},
+		{
+			name: "batch get",
+			fn: func(s *crud, cfg config.CrudParams, p processParams) error {
+				return s.processBatch(cfg, METHOD_BATCH_GET, p)
+			},
+			expected: "-- name: BatchGetAuthor :batchone\nSELECT * FROM authors WHERE id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name: "batch update",
+			fn: func(s *crud, cfg config.CrudParams, p processParams) error {
+				return s.processBatch(cfg, METHOD_BATCH_UPDATE, p)
+			},
+			method:   config.Method{SkipColumns: []string{"id", "created_at"}},
+			expected: "-- name: BatchUpdateAuthor :batchexec\nUPDATE authors\n\tSET name=$1\n\tWHERE id=$2;\n\n",
+		},
+		{
+			name: "batch delete",
+			fn: func(s *crud, cfg config.CrudParams, p processParams) error {
+				return s.processBatch(cfg, METHOD_BATCH_DELETE, p)
+			},
+			expected: "-- name: BatchDeleteAuthor :batchexec\nDELETE FROM authors WHERE id=$1;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "authors",
+				metaData:     metaData,
+				methodParams: tc.method,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "author_id", "deleted_at"},
+		primaryColumns: []string{"id"},
+		foreignKeys:    []foreignKey{{columns: []string{"author_id"}}},
+	}
+	tableParams := config.TableParams{
+		SoftDelete: config.SoftDeleteParams{Column: "deleted_at"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		engine   engineType
+		method   config.Method
+		expected string
+	}{
+		{
+			name: "delete",
+			fn:   (*crud).processDelete,
+			expected: "-- name: DeleteBook :exec\nUPDATE books\n\tSET deleted_at=now()\n\tWHERE deleted_at IS NULL AND id=$1;\n\n" +
+				"-- name: RestoreBook :exec\nUPDATE books\n\tSET deleted_at=NULL\n\tWHERE id=$1;\n\n" +
+				"-- name: HardDeleteBook :exec\nDELETE FROM books WHERE id=$1;\n\n",
+		},
+		{
+			name:   "sqlite delete by relation",
+			engine: EngineTypeSqlite,
+			fn: func(s *crud, cfg config.CrudParams, p processParams) error {
+				return s.processRelation(cfg, METHOD_DELETE_BY, p)
+			},
+			expected: "-- name: DeleteBooksByAuthorID :exec\nUPDATE books\n\tSET deleted_at=CURRENT_TIMESTAMP\n\tWHERE author_id=? AND deleted_at IS NULL;\n\n",
+		},
+		{
+			name:     "get",
+			fn:       (*crud).processGet,
+			expected: "-- name: GetBook :one\nSELECT * FROM books WHERE deleted_at IS NULL AND id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name: "find deleted",
+			fn:   (*crud).processFind,
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{"deleted_at": {Value: "IS NOT NULL"}},
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books WHERE deleted_at IS NOT NULL;\n\n",
+		},
+		{
+			name:     "total",
+			fn:       (*crud).processTotal,
+			expected: "-- name: TotalBooks :one\nSELECT count(1) as total FROM books WHERE deleted_at IS NULL;\n\n",
+		},
+		{
+			name:     "exists",
+			fn:       (*crud).processExists,
+			expected: "-- name: ExistsBook :one\nSELECT EXISTS (SELECT 1 FROM books WHERE deleted_at IS NULL LIMIT 1)::boolean;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "books",
+				metaData:     metaData,
+				methodParams: tc.method,
+				tableParams:  tableParams,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVersionColumn(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "name", "version"},
+		primaryColumns: []string{"id"},
+	}
+	tableParams := config.TableParams{VersionColumn: "version"}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		engine   engineType
+		method   config.Method
+		expected string
+	}{
+		{
+			name:     "update",
+			fn:       (*crud).processUpdate,
+			method:   config.Method{SkipColumns: []string{"id", "version"}},
+			expected: "-- name: UpdateDocument :execrows\nUPDATE documents\n\tSET name=$1, version=version+1\n\tWHERE id=$2 AND version=$3;\n\n",
+		},
+		{
+			name:   "update returning",
+			fn:     (*crud).processUpdate,
+			engine: EngineTypeSqlite,
+			method: config.Method{SkipColumns: []string{"id"}, Returning: "*"},
+			expected: "-- name: UpdateDocument :one\nUPDATE documents\n\tSET name=?, version=version+1\n\tWHERE id=? AND version=?" +
+				"\n\tRETURNING *;\n\n",
+		},
+		{
+			name:     "create",
+			fn:       (*crud).processCreate,
+			expected: "-- name: CreateDocument :exec\nINSERT INTO documents (id, name)\n\tVALUES ($1, $2);\n\n",
+		},
+		{
+			name: "upsert",
+			fn:   (*crud).processUpsert,
+			expected: "-- name: UpsertDocument :exec\nINSERT INTO documents (id, name)\n\tVALUES ($1, $2)" +
+				"\n\tON CONFLICT (id) DO UPDATE\n\tSET name=EXCLUDED.name, version=documents.version+1;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "documents",
+				metaData:     metaData,
+				methodParams: tc.method,
+				tableParams:  tableParams,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBatchUpdateVersionColumn(t *testing.T) {
+	p := processParams{
+		table: "documents",
+		metaData: tableMetaData{
+			columns:        []string{"id", "name", "version"},
+			primaryColumns: []string{"id"},
+		},
+		methodParams: config.Method{SkipColumns: []string{"id"}},
+		tableParams:  config.TableParams{VersionColumn: "version"},
+		engine:       EngineTypePostgres,
+	}
+
+	p.builder = new(strings.Builder)
+	err := (&crud{}).processBatch(config.CrudParams{}, METHOD_BATCH_UPDATE, p)
+	if err == nil || err.Error() != "batch_update with version_column requires returning" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	p.methodParams.Returning = "version"
+	actual := runProcess(t, func(s *crud, cfg config.CrudParams, p processParams) error {
+		return s.processBatch(cfg, METHOD_BATCH_UPDATE, p)
+	}, config.CrudParams{}, p)
+
+	expected := "-- name: BatchUpdateDocument :batchone\nUPDATE documents\n\tSET name=$1, version=version+1\n\tWHERE id=$2 AND version=$3" +
+		"\n\tRETURNING version;\n\n"
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("sql mismatch:\n%s", diff)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "name", "email", "updated_at", "version"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		engine      engineType
+		tableParams config.TableParams
+		method      config.Method
+		expected    string
+	}{
+		{
+			name: "all columns",
+			method: config.Method{
+				SkipColumns:  []string{"version"},
+				ColumnValues: map[string]string{"updated_at": "now()"},
+				Returning:    "*",
+			},
+			expected: "-- name: PatchUser :one\nUPDATE users\n\tSET name=COALESCE(sqlc.narg('name'), name),\n\t\temail=COALESCE(sqlc.narg('email'), email),\n\t\tupdated_at=now()" +
+				"\n\tWHERE id=sqlc.arg('id')\n\tRETURNING *;\n\n",
+		},
+		{
+			name:        "update columns with version",
+			engine:      EngineTypeSqlite,
+			tableParams: config.TableParams{VersionColumn: "version"},
+			method: config.Method{
+				UpdateColumns: []string{"email"},
+			},
+			expected: "-- name: PatchUser :execrows\nUPDATE users\n\tSET email=COALESCE(sqlc.narg('email'), email),\n\t\tversion=version+1" +
+				"\n\tWHERE id=sqlc.arg('id') AND version=sqlc.arg('version');\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, (*crud).processPatch, config.CrudParams{}, processParams{
+				table:        "users",
+				metaData:     metaData,
+				methodParams: tc.method,
+				tableParams:  tc.tableParams,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWhereOperators(t *testing.T) {
+	metaData := tableMetaData{
+		columns:     []string{"id", "author_id", "name", "created_at"},
+		columnTypes: map[string]string{"author_id": "uuid"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		engine   engineType
+		method   config.Method
+		expected string
+	}{
+		{
+			name: "postgresql",
+			fn:   (*crud).processFind,
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{
+					"author_id":  {Operator: "in", Optional: true},
+					"name":       {Operator: "ilike", Optional: true},
+					"created_at": {Operator: "between"},
+				},
+				Limit: true,
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books " +
+				"WHERE (author_id = ANY(sqlc.narg('author_id')::uuid[]) OR sqlc.narg('author_id') IS NULL) " +
+				"AND created_at BETWEEN sqlc.arg('created_at_from') AND sqlc.arg('created_at_to') " +
+				"AND (name ILIKE sqlc.narg('name') OR sqlc.narg('name') IS NULL) " +
+				"LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');\n\n",
+		},
+		{
+			name:   "mysql",
+			fn:     (*crud).processFind,
+			engine: EngineTypeMysql,
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{
+					"author_id":  {Operator: "in"},
+					"name":       {Operator: "ilike"},
+					"created_at": {Operator: "between", Optional: true},
+				},
+				Limit: true,
+			},
+			expected: "-- name: FindBooks :many\nSELECT * FROM books " +
+				"WHERE author_id IN (sqlc.slice('author_id')) " +
+				"AND ((created_at >= sqlc.narg('created_at_from') OR sqlc.narg('created_at_from') IS NULL) " +
+				"AND (created_at <= sqlc.narg('created_at_to') OR sqlc.narg('created_at_to') IS NULL)) " +
+				"AND name LIKE sqlc.arg('name') LIMIT ? OFFSET ?;\n\n",
+		},
+		{
+			name:   "positional",
+			fn:     (*crud).processTotal,
+			engine: EngineTypePostgres,
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{
+					"created_at": {Operator: "gte"},
+					"name":       {Operator: "like"},
+				},
+			},
+			expected: "-- name: TotalBooks :one\nSELECT count(1) as total FROM books WHERE created_at>=$1 AND name LIKE $2;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "books",
+				metaData:     metaData,
+				methodParams: tc.method,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOptionalInSqlite(t *testing.T) {
+	err := (&crud{}).processFind(config.CrudParams{}, processParams{
+		builder:  new(strings.Builder),
+		table:    "books",
+		metaData: tableMetaData{columns: []string{"author_id"}},
+		methodParams: config.Method{
+			Where: map[string]config.WhereParamsItem{"author_id": {Operator: "in", Optional: true}},
+		},
+		engine: EngineTypeSqlite,
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestResolveTables(t *testing.T) {
+	data := tables{
+		"users":             {},
+		"user_roles":        {},
+		"books":             {},
+		"schema_migrations": {},
+	}
+
+	crudParams := config.CrudParams{
+		Default: config.DefaultParams{
+			Methods: map[config.MethodType]config.Method{
+				METHOD_GET:    {},
+				METHOD_UPDATE: {ColumnValues: map[string]string{"updated_at": "now()"}},
+			},
+		},
+		Tables: config.Table{
+			"*": {
+				Exclude: []string{"schema_migrations", "/^user_/"},
+				Methods: map[config.MethodType]config.Method{
+					METHOD_ALL:  {Returning: "*"},
+					METHOD_FIND: {Limit: true},
+				},
+			},
+			"user_*": {
+				OutputDir: "sql/queries/users",
+			},
+			"users": {
+				Methods: map[config.MethodType]config.Method{
+					METHOD_UPDATE: {},
+					METHOD_CREATE: {Name: "CreateUserWithRole"},
+				},
+			},
+		},
+	}
+
+	actual, err := resolveTables(crudParams, data, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	books := map[config.MethodType]config.Method{
+		METHOD_CREATE: {Returning: "*"},
+		METHOD_UPDATE: {Returning: "*"},
+		METHOD_DELETE: {Returning: "*"},
+		METHOD_GET:    {Returning: "*"},
+		METHOD_FIND:   {Limit: true},
+		METHOD_TOTAL:  {Returning: "*"},
+		METHOD_EXISTS: {Returning: "*"},
+	}
+
+	expected := config.Table{
+		"books": {Methods: books},
+		"user_roles": {
+			OutputDir: "sql/queries/users",
+			Methods: map[config.MethodType]config.Method{
+				METHOD_GET:    {},
+				METHOD_UPDATE: {ColumnValues: map[string]string{"updated_at": "now()"}},
+			},
+		},
+		"users": {
+			Methods: map[config.MethodType]config.Method{
+				METHOD_GET:    {},
+				METHOD_UPDATE: {ColumnValues: map[string]string{"updated_at": "now()"}},
+				METHOD_CREATE: {Name: "CreateUserWithRole"},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("tables mismatch:\n%s", diff)
+	}
+}
+
+func TestResolveDefaultSchemaTables(t *testing.T) {
+	data := tables{
+		"invoices":         {},
+		"payments":         {},
+		"billing.invoices": {},
+	}
+
+	crudParams := config.CrudParams{
+		Tables: config.Table{
+			"public.invoices": {OutputDir: "sql/queries/invoices"},
+			"public.*":        {OutputDir: "sql/queries/public"},
+		},
+	}
+
+	actual, err := resolveTables(crudParams, data, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := config.Table{
+		"invoices": {OutputDir: "sql/queries/invoices", Methods: map[config.MethodType]config.Method{}},
+		"payments": {OutputDir: "sql/queries/public", Methods: map[config.MethodType]config.Method{}},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("tables mismatch:\n%s", diff)
+	}
+
+	for tableName := range actual {
+		if data.getTableMetaData(tableName) == nil {
+			t.Errorf("table %s does not exist", tableName)
+		}
+	}
+
+	crudParams.Tables["invoices"] = config.TableParams{}
+	if _, err := resolveTables(crudParams, data, "public"); err == nil {
+		t.Error("expected error for table configured twice")
+	}
+}
+
+func TestDerivedMethods(t *testing.T) {
+	s := &crud{
+		catalogs: map[string]cmd.GetCatalogResultItem{
+			"repo": {
+				Catalog: &catalog.Catalog{
+					DefaultSchema: "public",
+					Schemas: []*catalog.Schema{
+						{Name: "public", Tables: []*catalog.Table{{
+							Rel:        &ast.TableName{Name: "users"},
+							PrimaryKey: []string{"id"},
+							Columns: []*catalog.Column{
+								{Name: "id", Type: ast.TypeName{Name: "int8"}},
+								{Name: "deleted_at", Type: ast.TypeName{Name: "timestamptz"}},
+							},
+						}}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, methodType := range []config.MethodType{METHOD_RESTORE, METHOD_HARD_DELETE} {
+		t.Run(methodType.String(), func(t *testing.T) {
+			crudParams := config.CrudParams{
+				Tables: config.Table{
+					"users": {
+						SoftDelete: config.SoftDeleteParams{Column: "deleted_at"},
+						Methods:    map[config.MethodType]config.Method{METHOD_DELETE: {}, methodType: {}},
+					},
+				},
+			}
+
+			_, _, err := s.generateSQLForEachTable(crudParams, []generateSQLForEachTableParams{
+				{outputPath: "repo", engine: string(EngineTypePostgres)},
+			})
+			if !errors.Is(err, ErrDerivedMethod) {
+				t.Fatalf("expected %v, got %v", ErrDerivedMethod, err)
+			}
+		})
+	}
+}
+
+func TestSharedMethodParams(t *testing.T) {
+	table := func(name string) *catalog.Table {
+		return &catalog.Table{
+			Rel:        &ast.TableName{Name: name},
+			PrimaryKey: []string{"id"},
+			Columns: []*catalog.Column{
+				{Name: "id", Type: ast.TypeName{Name: "int8"}},
+				{Name: "tenant_id", Type: ast.TypeName{Name: "int8"}},
+			},
+		}
+	}
+
+	s := &crud{
+		catalogs: map[string]cmd.GetCatalogResultItem{
+			"repo": {
+				Catalog: &catalog.Catalog{
+					DefaultSchema: "public",
+					Schemas: []*catalog.Schema{
+						{Name: "public", Tables: []*catalog.Table{table("authors"), table("tags")}},
+					},
+				},
+			},
+		},
+	}
+
+	where := map[string]config.WhereParamsItem{"tenant_id": {}}
+
+	crudParams := config.CrudParams{
+		Default: config.DefaultParams{
+			Methods: map[config.MethodType]config.Method{
+				METHOD_GET: {Where: where},
+			},
+		},
+		Tables: config.Table{
+			"authors": {
+				Methods: map[config.MethodType]config.Method{
+					METHOD_ALL: {Where: where},
+				},
+			},
+			"tags": {},
+		},
+	}
+
+	sqlData, _, err := s.generateSQLForEachTable(crudParams, []generateSQLForEachTableParams{
+		{outputPath: "repo", engine: string(EngineTypePostgres)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := make(map[string]string)
+	for tableName, queries := range sqlData {
+		for _, item := range queries {
+			actual[tableName+"."+item.method] = string(item.data)
+		}
+	}
+
+	for key, expected := range map[string]string{
+		"authors.get":   "-- name: GetAuthor :one\nSELECT * FROM authors WHERE id=$1 AND tenant_id=$2 LIMIT 1;\n\n",
+		"authors.total": "-- name: TotalAuthors :one\nSELECT count(1) as total FROM authors WHERE tenant_id=$1;\n\n",
+		"tags.get":      "-- name: GetTag :one\nSELECT * FROM tags WHERE id=$1 AND tenant_id=$2 LIMIT 1;\n\n",
+	} {
+		if diff := cmp.Diff(expected, actual[key]); diff != "" {
+			t.Errorf("%s sql mismatch:\n%s", key, diff)
+		}
+	}
+
+	if len(where) != 1 {
+		t.Errorf("where params of the config are modified: %v", where)
+	}
+}
+
+func TestSchemaQualifiedTables(t *testing.T) {
+	invoices := func(schema string) *catalog.Table {
+		return &catalog.Table{
+			Rel:        &ast.TableName{Schema: schema, Name: "invoices"},
+			PrimaryKey: []string{"id"},
+			Columns:    []*catalog.Column{{Name: "id", Type: ast.TypeName{Name: "int8"}}},
+		}
+	}
+
+	s := &crud{
+		catalogs: map[string]cmd.GetCatalogResultItem{
+			"repo": {
+				Catalog: &catalog.Catalog{
+					DefaultSchema: "public",
+					Schemas: []*catalog.Schema{
+						{Name: "public", Tables: []*catalog.Table{invoices("")}},
+						{Name: "billing", Tables: []*catalog.Table{
+							invoices("billing"),
+							{Rel: &ast.TableName{Schema: "billing", Name: "payments"}},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := s.getTableMeta("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]string, len(data))
+	for key, metaData := range data {
+		names[key] = metaData.name
+	}
+
+	expectedNames := map[string]string{
+		"invoices":         "invoices",
+		"billing.invoices": "billing_invoices",
+		"billing.payments": "payments",
+	}
+	if diff := cmp.Diff(expectedNames, names); diff != "" {
+		t.Errorf("table names mismatch:\n%s", diff)
+	}
+
+	actual := runProcess(t, (*crud).processGet, config.CrudParams{}, processParams{
+		table:    "billing.invoices",
+		metaData: *data["billing.invoices"],
+	})
+
+	expected := "-- name: GetBillingInvoice :one\nSELECT * FROM billing.invoices WHERE id=$1 LIMIT 1;\n\n"
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("sql mismatch:\n%s", diff)
+	}
+}
+
+func TestDialects(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "order", "key"},
+		columnTypes:    map[string]string{"id": "int8", "order": "int4", "key": "text"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		engine   engineType
+		table    string
+		method   config.Method
+		expected string
+	}{
+		{
+			name:   "postgresql create",
+			fn:     (*crud).processCreate,
+			engine: EngineTypePostgres,
+			table:  "user",
+			method: config.Method{SkipColumns: []string{"id"}},
+			expected: "-- name: CreateUser :exec\nINSERT INTO \"user\" (\"order\", key)\n" +
+				"\tVALUES ($1, $2);\n\n",
+		},
+		{
+			name:   "mysql create",
+			fn:     (*crud).processCreate,
+			engine: EngineTypeMysql,
+			table:  "user",
+			method: config.Method{SkipColumns: []string{"id"}},
+			expected: "-- name: CreateUser :exec\nINSERT INTO user (`order`, `key`)\n" +
+				"\tVALUES (?, ?);\n\n",
+		},
+		{
+			name:   "sqlite find",
+			fn:     (*crud).processFind,
+			engine: EngineTypeSqlite,
+			table:  "billing.user",
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{"key": {}},
+				Order: config.OrderParam{By: "order", Direction: "ASC"},
+				Limit: true,
+			},
+			expected: "-- name: FindBillingUser :many\n" +
+				"SELECT * FROM billing.user WHERE \"key\"=? ORDER BY \"order\" ASC LIMIT ? OFFSET ?;\n\n",
+		},
+		{
+			name:     "postgresql exists",
+			fn:       (*crud).processExists,
+			engine:   EngineTypePostgres,
+			table:    "books",
+			expected: "-- name: ExistsBook :one\nSELECT EXISTS (SELECT 1 FROM books LIMIT 1)::boolean;\n\n",
+		},
+		{
+			name:     "mysql exists",
+			fn:       (*crud).processExists,
+			engine:   EngineTypeMysql,
+			table:    "books",
+			expected: "-- name: ExistsBook :one\nSELECT EXISTS (SELECT 1 FROM books LIMIT 1);\n\n",
+		},
+		{
+			name:     "sqlite exists",
+			fn:       (*crud).processExists,
+			engine:   EngineTypeSqlite,
+			table:    "books",
+			expected: "-- name: ExistsBook :one\nSELECT CAST(EXISTS (SELECT 1 FROM books LIMIT 1) AS BOOLEAN);\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        tc.table,
+				metaData:     metaData,
+				methodParams: tc.method,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReturningNotSupported(t *testing.T) {
+	err := (&crud{}).processCreate(config.CrudParams{}, processParams{
+		builder:      new(strings.Builder),
+		table:        "books",
+		metaData:     tableMetaData{columns: []string{"id"}},
+		methodParams: config.Method{Returning: "*"},
+		engine:       EngineTypeMysql,
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCheckQueries(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(schema, []byte("CREATE TABLE books (id int PRIMARY KEY, name text NOT NULL);"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := compiler.NewCompiler(sqlcconfig.SQL{Engine: sqlcconfig.EnginePostgreSQL}, sqlcconfig.CombinedSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ParseCatalog([]string{schema}); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := runProcess(t, (*crud).processGet, config.CrudParams{}, processParams{
+		table:    "books",
+		metaData: tableMetaData{columns: []string{"id", "name"}, primaryColumns: []string{"id"}},
+	})
+	if err := checkQueries(c, valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := runProcess(t, (*crud).processCreate, config.CrudParams{}, processParams{
+		table:        "books",
+		metaData:     tableMetaData{columns: []string{"id", "name"}},
+		methodParams: config.Method{Returning: "id, author_id"},
+	})
+	err = checkQueries(c, invalid)
+	if err == nil || !strings.Contains(err.Error(), `column "author_id" does not exist`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "name", "notifications", "created_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		method   config.Method
+		expected string
+	}{
+		{
+			name:     "get select columns",
+			fn:       (*crud).processGet,
+			method:   config.Method{SelectColumns: []string{"id", "name"}},
+			expected: "-- name: GetUser :one\nSELECT id, name FROM users WHERE id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name:     "find exclude columns",
+			fn:       (*crud).processFind,
+			method:   config.Method{ExcludeColumns: []string{"notifications"}},
+			expected: "-- name: FindUsers :many\nSELECT id, name, created_at FROM users;\n\n",
+		},
+		{
+			name: "find select and exclude columns",
+			fn:   (*crud).processFind,
+			method: config.Method{
+				SelectColumns:  []string{"name", "notifications", "id"},
+				ExcludeColumns: []string{"notifications"},
+			},
+			expected: "-- name: FindUsers :many\nSELECT name, id FROM users;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "users",
+				metaData:     metaData,
+				methodParams: tc.method,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	err := (&crud{}).processFind(config.CrudParams{}, processParams{
+		builder:      new(strings.Builder),
+		table:        "users",
+		metaData:     metaData,
+		methodParams: config.Method{SelectColumns: []string{"email"}},
+		engine:       EngineTypePostgres,
+	})
+	if err == nil {
+		t.Fatal("expected error for undefined column")
+	}
+}
+
+func TestManyMethods(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "status", "updated_at", "deleted_at"},
+		columnTypes:    map[string]string{"id": "uuid", "status": "text"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		fn         processFunc
+		method     config.Method
+		engine     engineType
+		softDelete config.SoftDeleteParams
+		expected   string
+	}{
+		{
+			name:     "get many",
+			fn:       (*crud).processGetMany,
+			expected: "-- name: GetManyUsers :many\nSELECT * FROM users WHERE id = ANY(sqlc.arg('id')::uuid[]);\n\n",
+		},
+		{
+			name:     "get many mysql",
+			fn:       (*crud).processGetMany,
+			engine:   EngineTypeMysql,
+			expected: "-- name: GetManyUsers :many\nSELECT * FROM users WHERE id IN (sqlc.slice('id'));\n\n",
+		},
+		{
+			name:       "delete many soft delete",
+			fn:         (*crud).processDeleteMany,
+			softDelete: config.SoftDeleteParams{Column: "deleted_at"},
+			expected:   "-- name: DeleteManyUsers :execrows\nUPDATE users\n\tSET deleted_at=now()\n\tWHERE deleted_at IS NULL AND id = ANY(sqlc.arg('id')::uuid[]);\n\n",
+		},
+		{
+			name:     "delete many sqlite",
+			fn:       (*crud).processDeleteMany,
+			engine:   EngineTypeSqlite,
+			expected: "-- name: DeleteManyUsers :execrows\nDELETE FROM users WHERE id IN (sqlc.slice('id'));\n\n",
+		},
+		{
+			name: "update many columns",
+			fn:   (*crud).processUpdateManyColumns,
+			method: config.Method{
+				UpdateColumns: []string{"status"},
+				ColumnValues:  map[string]string{"updated_at": "now()"},
+			},
+			expected: "-- name: UpdateManyUsers :execrows\nUPDATE users\n\tSET status=sqlc.arg('status'), updated_at=now()\n\tWHERE id = ANY(sqlc.arg('id')::uuid[]);\n\n",
+		},
+		{
+			name:     "update many columns sqlite",
+			fn:       (*crud).processUpdateManyColumns,
+			engine:   EngineTypeSqlite,
+			method:   config.Method{UpdateColumns: []string{"status"}},
+			expected: "-- name: UpdateManyUsers :execrows\nUPDATE users\n\tSET status=sqlc.arg('status')\n\tWHERE id IN (sqlc.slice('id'));\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "users",
+				metaData:     metaData,
+				methodParams: tc.method,
+				tableParams:  config.TableParams{SoftDelete: tc.softDelete},
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	err := (&crud{}).processGetMany(config.CrudParams{}, processParams{
+		builder:  new(strings.Builder),
+		table:    "users",
+		metaData: tableMetaData{primaryColumns: []string{"id", "status"}},
+		engine:   EngineTypePostgres,
+	})
+	if err == nil {
+		t.Fatal("expected error for composite primary key")
+	}
+}
+
+func TestUpdateColumns(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "status", "password_hash", "salt", "updated_at", "version"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		key           string
+		method        config.Method
+		versionColumn string
+		expected      string
+	}{
+		{
+			name:     "column from key",
+			key:      "status",
+			method:   config.Method{ColumnValues: map[string]string{"updated_at": "now()"}},
+			expected: "-- name: UpdateUserStatus :exec\nUPDATE users\n\tSET status=$1, updated_at=now()\n\tWHERE id=$2;\n\n",
+		},
+		{
+			name: "update columns with returning",
+			key:  "password",
+			method: config.Method{
+				UpdateColumns: []string{"password_hash", "salt"},
+				Returning:     "*",
+			},
+			expected: "-- name: UpdateUserPassword :one\nUPDATE users\n\tSET password_hash=$1, salt=$2\n\tWHERE id=$3\n\tRETURNING *;\n\n",
+		},
+		{
+			name:          "version column",
+			key:           "status",
+			versionColumn: "version",
+			expected:      "-- name: UpdateUserStatus :execrows\nUPDATE users\n\tSET status=$1, version=version+1\n\tWHERE id=$2 AND version=$3;\n\n",
+		},
+		{
+			name:     "custom name",
+			key:      "status",
+			method:   config.Method{Name: "SetUserStatus"},
+			expected: "-- name: SetUserStatus :exec\nUPDATE users\n\tSET status=$1\n\tWHERE id=$2;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, func(s *crud, cfg config.CrudParams, p processParams) error {
+				return s.processUpdateColumns(cfg, tc.key, p)
+			}, config.CrudParams{}, processParams{
+				table:        "users",
+				metaData:     metaData,
+				methodParams: tc.method,
+				tableParams:  config.TableParams{VersionColumn: tc.versionColumn},
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	err := (&crud{}).processUpdateColumns(config.CrudParams{}, "state", processParams{
+		builder:  new(strings.Builder),
+		table:    "users",
+		metaData: metaData,
+		engine:   EngineTypePostgres,
+	})
+	if err == nil {
+		t.Fatal("expected error for undefined column")
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "balance", "views_count", "updated_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		method   config.Method
+		engine   engineType
+		expected string
+	}{
+		{
+			name:     "returns new value",
+			method:   config.Method{UpdateColumns: []string{"views_count"}},
+			expected: "-- name: IncrementUserViewsCount :one\nUPDATE users\n\tSET views_count=views_count+sqlc.arg('views_count')\n\tWHERE id=sqlc.arg('id')\n\tRETURNING views_count;\n\n",
+		},
+		{
+			name: "guard and column values",
+			method: config.Method{
+				UpdateColumns: []string{"balance"},
+				Guard:         ">= 0",
+				ColumnValues:  map[string]string{"updated_at": "now()"},
+			},
+			expected: "-- name: IncrementUserBalance :one\nUPDATE users\n\tSET balance=balance+sqlc.arg('balance'), updated_at=now()\n\tWHERE id=sqlc.arg('id') AND balance+sqlc.arg('balance') >= 0\n\tRETURNING balance;\n\n",
+		},
+		{
+			name: "several columns with returning",
+			method: config.Method{
+				UpdateColumns: []string{"balance", "views_count"},
+				Returning:     "*",
+			},
+			expected: "-- name: IncrementUser :one\nUPDATE users\n\tSET balance=balance+sqlc.arg('balance'), views_count=views_count+sqlc.arg('views_count')\n\tWHERE id=sqlc.arg('id')\n\tRETURNING *;\n\n",
+		},
+		{
+			name:     "mysql",
+			engine:   EngineTypeMysql,
+			method:   config.Method{UpdateColumns: []string{"balance"}, Guard: ">= 0"},
+			expected: "-- name: IncrementUserBalance :execrows\nUPDATE users\n\tSET balance=balance+sqlc.arg('balance')\n\tWHERE id=sqlc.arg('id') AND balance+sqlc.arg('balance') >= 0;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, (*crud).processIncrement, config.CrudParams{}, processParams{
+				table:        "users",
+				metaData:     metaData,
+				methodParams: tc.method,
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "status", "created_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		method   config.Method
+		expected string
+	}{
+		{
+			name:     "get for update",
+			fn:       (*crud).processGet,
+			method:   config.Method{Lock: config.LockParams{ForUpdate: true}},
+			expected: "-- name: GetJob :one\nSELECT * FROM jobs WHERE id=$1 LIMIT 1 FOR UPDATE;\n\n",
+		},
+		{
+			name: "find for update skip locked",
+			fn:   (*crud).processFind,
+			method: config.Method{
+				Order: config.OrderParam{By: "created_at", Direction: "ASC"},
+				Limit: true,
+				Lock:  config.LockParams{ForUpdate: true, SkipLocked: true},
+			},
+			expected: "-- name: FindJobs :many\nSELECT * FROM jobs ORDER BY created_at ASC LIMIT $1 OFFSET $2 FOR UPDATE SKIP LOCKED;\n\n",
+		},
+		{
+			name:     "find for share nowait",
+			fn:       (*crud).processFind,
+			method:   config.Method{Lock: config.LockParams{ForShare: true, NoWait: true}},
+			expected: "-- name: FindJobs :many\nSELECT * FROM jobs FOR SHARE NOWAIT;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table:        "jobs",
+				metaData:     metaData,
+				methodParams: tc.method,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name   string
+		lock   config.LockParams
+		engine engineType
+	}{
+		{name: "for update and for share", lock: config.LockParams{ForUpdate: true, ForShare: true}, engine: EngineTypePostgres},
+		{name: "skip locked without mode", lock: config.LockParams{SkipLocked: true}, engine: EngineTypePostgres},
+		{name: "skip locked and nowait", lock: config.LockParams{ForUpdate: true, SkipLocked: true, NoWait: true}, engine: EngineTypePostgres},
+		{name: "sqlite", lock: config.LockParams{ForUpdate: true}, engine: EngineTypeSqlite},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := (&crud{}).processFind(config.CrudParams{}, processParams{
+				builder:      new(strings.Builder),
+				table:        "jobs",
+				metaData:     metaData,
+				methodParams: config.Method{Lock: tc.lock},
+				engine:       tc.engine,
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestDequeue(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "status", "created_at", "deleted_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	method := config.Method{
+		Where: map[string]config.WhereParamsItem{
+			"status": {Operator: "=", Value: "'pending'"},
+		},
+		ColumnValues: map[string]string{"status": "'processing'"},
+		Order:        config.OrderParam{By: "created_at", Direction: "ASC"},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		engine     engineType
+		softDelete config.SoftDeleteParams
+		expected   string
+	}{
+		{
+			name:     "postgres",
+			expected: "-- name: DequeueJobs :many\nUPDATE jobs\n\tSET status='processing'\n\tWHERE id IN (\n\t\tSELECT id FROM jobs WHERE status = 'pending' ORDER BY created_at ASC LIMIT sqlc.arg('limit') FOR UPDATE SKIP LOCKED\n\t)\n\tRETURNING *;\n\n",
+		},
+		{
+			name:       "soft delete",
+			softDelete: config.SoftDeleteParams{Column: "deleted_at"},
+			expected:   "-- name: DequeueJobs :many\nUPDATE jobs\n\tSET status='processing'\n\tWHERE id IN (\n\t\tSELECT id FROM jobs WHERE deleted_at IS NULL AND status = 'pending' ORDER BY created_at ASC LIMIT sqlc.arg('limit') FOR UPDATE SKIP LOCKED\n\t)\n\tRETURNING *;\n\n",
+		},
+		{
+			name:     "sqlite",
+			engine:   EngineTypeSqlite,
+			expected: "-- name: DequeueJobs :many\nUPDATE jobs\n\tSET status='processing'\n\tWHERE id IN (\n\t\tSELECT id FROM jobs WHERE status = 'pending' ORDER BY created_at ASC LIMIT sqlc.arg('limit')\n\t)\n\tRETURNING *;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, (*crud).processDequeue, config.CrudParams{}, processParams{
+				table:        "jobs",
+				metaData:     metaData,
+				methodParams: method,
+				tableParams:  config.TableParams{SoftDelete: tc.softDelete},
+				engine:       tc.engine,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	err := (&crud{}).processDequeue(config.CrudParams{}, processParams{
+		builder:      new(strings.Builder),
+		table:        "jobs",
+		metaData:     metaData,
+		methodParams: method,
+		engine:       EngineTypeMysql,
+	})
+	if err == nil {
+		t.Fatal("expected error for mysql")
+	}
+}
+
+// TestDequeueSqliteConcurrency checks that sqlite workers do not claim the same rows without row locking
+func TestDequeueSqliteConcurrency(t *testing.T) {
+	query := runProcess(t, (*crud).processDequeue, config.CrudParams{}, processParams{
+		table: "jobs",
+		metaData: tableMetaData{
+			columns:        []string{"id", "status"},
+			primaryColumns: []string{"id"},
+		},
+		methodParams: config.Method{
+			Where:        map[string]config.WhereParamsItem{"status": {Operator: "=", Value: "'pending'"}},
+			ColumnValues: map[string]string{"status": "'processing'"},
+			Returning:    "id",
+		},
+		engine: EngineTypeSqlite,
+	})
+	query = strings.ReplaceAll(query, "sqlc.arg('limit')", "?")
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "jobs.db")+"?_pragma=busy_timeout(10000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE TABLE jobs (id integer PRIMARY KEY, status text NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	const jobs = 200
+	for i := 1; i <= jobs; i++ {
+		if _, err := db.Exec("INSERT INTO jobs (id, status) VALUES (?, 'pending')", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[int]int)
+		wg      sync.WaitGroup
+		errs    = make(chan error, 4)
+	)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				rows, err := db.Query(query, 3)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				var ids []int
+				for rows.Next() {
+					var id int
+					if err := rows.Scan(&id); err != nil {
+						errs <- err
+						return
+					}
+					ids = append(ids, id)
+				}
+				if err := rows.Close(); err != nil {
+					errs <- err
+					return
+				}
+				if len(ids) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, id := range ids {
+					claimed[id]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if len(claimed) != jobs {
+		t.Errorf("expected %d claimed jobs, got %d", jobs, len(claimed))
+	}
+	for id, count := range claimed {
+		if count > 1 {
+			t.Errorf("job %d is claimed %d times", id, count)
+		}
+	}
+}
+
+func TestNamedParams(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "name", "order", "created_at"},
+		primaryColumns: []string{"id"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		method   config.Method
+		engine   engineType
+		expected string
+	}{
+		{
+			name: "create",
+			fn:   (*crud).processCreate,
+			method: config.Method{
+				SkipColumns:  []string{"id"},
+				ColumnValues: map[string]string{"created_at": "now()"},
+			},
+			expected: "-- name: CreateUser :exec\nINSERT INTO users (name, \"order\", created_at)\n\tVALUES (sqlc.arg('name'), sqlc.arg('order'), now());\n\n",
+		},
+		{
+			name:     "update primary key",
+			fn:       (*crud).processUpdate,
+			method:   config.Method{SkipColumns: []string{"created_at"}},
+			expected: "-- name: UpdateUser :exec\nUPDATE users\n\tSET id=sqlc.arg('new_id'), name=sqlc.arg('name'), \"order\"=sqlc.arg('order')\n\tWHERE id=sqlc.arg('id');\n\n",
+		},
+		{
+			name: "find with limit",
+			fn:   (*crud).processFind,
+			method: config.Method{
+				Where: map[string]config.WhereParamsItem{"order": {Operator: "gte"}},
+				Limit: true,
+			},
+			expected: "-- name: FindUsers :many\nSELECT * FROM users WHERE \"order\">=sqlc.arg('order') LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');\n\n",
+		},
+		{
+			name:     "find mysql",
+			fn:       (*crud).processFind,
+			method:   config.Method{Where: map[string]config.WhereParamsItem{"order": {Operator: "gte"}}},
+			engine:   EngineTypeMysql,
+			expected: "-- name: FindUsers :many\nSELECT * FROM users WHERE `order`>=sqlc.arg('order');\n\n",
+		},
+		{
+			name:     "delete sqlite",
+			fn:       (*crud).processDelete,
+			engine:   EngineTypeSqlite,
+			expected: "-- name: DeleteUser :exec\nDELETE FROM users WHERE id=sqlc.arg('id');\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{NamedParams: true}, processParams{
+				table:        "users",
+				metaData:     metaData,
+				methodParams: tc.method,
+				engine:       tc.engine,
+				namedParams:  true,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	// sqlc can not parse sqlc.arg in LIMIT of mysql
+	for _, method := range []config.Method{{Limit: true}, {Pagination: PAGINATION_KEYSET}} {
+		err := (&crud{}).processFind(config.CrudParams{NamedParams: true}, processParams{
+			builder:      new(strings.Builder),
+			table:        "users",
+			metaData:     metaData,
+			methodParams: method,
+			engine:       EngineTypeMysql,
+			namedParams:  true,
+		})
+		if err == nil || err.Error() != "named_params is not supported for limit of mysql" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestMethodNames(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		cfg        config.CrudParams
+		methodType config.MethodType
+		table      string
+		expected   string
+	}{
+		{name: "singular", methodType: METHOD_GET, table: "users", expected: "GetUser"},
+		{name: "plural", methodType: METHOD_FIND, table: "users", expected: "FindUsers"},
+		{name: "es suffix", methodType: METHOD_GET, table: "statuses", expected: "GetStatus"},
+		{name: "ss suffix", methodType: METHOD_UPDATE, table: "addresses", expected: "UpdateAddress"},
+		{name: "uncountable", methodType: METHOD_GET, table: "news", expected: "GetNews"},
+		{name: "singular table name", methodType: METHOD_TOTAL, table: "user", expected: "TotalUser"},
+		{name: "singular table name find", methodType: METHOD_FIND, table: "user", expected: "FindUser"},
+		{name: "uncountable data", methodType: METHOD_GET, table: "data", expected: "GetData"},
+		{name: "uncountable last word", methodType: METHOD_UPDATE, table: "user_data", expected: "UpdateUserData"},
+		{name: "last word", methodType: METHOD_GET, table: "user_categories", expected: "GetUserCategory"},
+		{
+			name:       "irregular words",
+			cfg:        config.CrudParams{IrregularWords: map[string]string{"staff": "staff"}},
+			methodType: METHOD_FIND,
+			table:      "company_staff",
+			expected:   "FindCompanyStaff",
+		},
+		{
+			name:       "overlapping irregular words",
+			cfg:        config.CrudParams{IrregularWords: map[string]string{"person": "people", "people": "peoples"}},
+			methodType: METHOD_GET,
+			table:      "people",
+			expected:   "GetPeople",
+		},
+		{
+			name:       "exclude table name",
+			cfg:        config.CrudParams{ExcludeTableNameFromMethods: true},
+			methodType: METHOD_CREATE_BULK,
+			table:      "users",
+			expected:   "CreateBulk",
+		},
+		{
+			name:       "template",
+			cfg:        config.CrudParams{MethodNameTemplate: "{{.Singular}}{{.Method}}"},
+			methodType: METHOD_GET,
+			table:      "statuses",
+			expected:   "StatusGet",
+		},
+		{
+			name:       "template with table",
+			cfg:        config.CrudParams{MethodNameTemplate: "{{.Method}}{{.Table}}"},
+			methodType: METHOD_HARD_DELETE,
+			table:      "users",
+			expected:   "HardDeleteUsers",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// method names must not depend on the order of irregular words
+			for range 10 {
+				actual := (&crud{}).getMethodName(tc.cfg, tc.methodType, tc.table)
+				if actual != tc.expected {
+					t.Fatalf("expected %s, got %s", tc.expected, actual)
+				}
+			}
+		})
+	}
+
+	for _, text := range []string{"{{.Method", "{{.Name}}", " "} {
+		if err := checkMethodNameTemplate(config.CrudParams{MethodNameTemplate: text}); err == nil {
+			t.Errorf("expected error for template %q", text)
+		}
+	}
+}
+
+func TestGetFiles(t *testing.T) {
+	s := &crud{config: config.Config{Pgxgen: config.Pgxgen{Version: "test-version"}}}
+	footer := generatedMarker + "\n-- versions:\n--   pgxgen test-version\n"
+
+	sqlData := map[string][]generatedQueries{
+		"users": {
+			{method: "get", data: []byte("get users;\n")},
+			{method: "find", data: []byte("find users;\n")},
+		},
+		"billing.invoices": {
+			{method: "get", data: []byte("get invoices;\n")},
+		},
+		"billing.payments": {
+			{method: "get", data: []byte("get payments;\n")},
+		},
+	}
+	tablesParams := config.Table{
+		"users":            {},
+		"billing.invoices": {},
+		"billing.payments": {OutputDir: "billing"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		cfg      config.CrudParams
+		expected map[string]string
+	}{
+		{
+			name: "table layout",
+			expected: map[string]string{
+				"queries/users_gen.sql":            "get users;\nfind users;\n" + footer,
+				"queries/billing_invoices_gen.sql": "get invoices;\n" + footer,
+				"billing/billing_payments_gen.sql": "get payments;\n" + footer,
+			},
+		},
+		{
+			name: "method layout",
+			cfg:  config.CrudParams{Layout: LAYOUT_METHOD},
+			expected: map[string]string{
+				"queries/users_get_gen.sql":            "get users;\n" + footer,
+				"queries/users_find_gen.sql":           "find users;\n" + footer,
+				"queries/billing_invoices_get_gen.sql": "get invoices;\n" + footer,
+				"billing/billing_payments_get_gen.sql": "get payments;\n" + footer,
+			},
+		},
+		{
+			name: "schema layout",
+			cfg:  config.CrudParams{Layout: LAYOUT_SCHEMA},
+			expected: map[string]string{
+				"queries/public_gen.sql":  "get users;\nfind users;\n" + footer,
+				"queries/billing_gen.sql": "get invoices;\n" + footer,
+				"billing/billing_gen.sql": "get payments;\n" + footer,
+			},
+		},
+		{
+			name: "file name template",
+			cfg:  config.CrudParams{FileNameTemplate: "{{.Schema}}.{{.Table}}.sql"},
+			expected: map[string]string{
+				"queries/public.users.sql":             "get users;\nfind users;\n" + footer,
+				"queries/billing.billing_invoices.sql": "get invoices;\n" + footer,
+				"billing/billing.billing_payments.sql": "get payments;\n" + footer,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := s.getFiles(tc.cfg, sqlData, tablesParams, []string{"queries"}, "public")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := make(map[string]string, len(files))
+			for k, v := range files {
+				actual[k] = string(v)
+			}
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("files mismatch:\n%s", diff)
+			}
+		})
+	}
+
+	for _, cfg := range []config.CrudParams{
+		{Layout: "unknown"},
+		{FileNameTemplate: "{{.Table"},
+		{FileNameTemplate: "queries.sql"},
+		{FileNameTemplate: "{{.Schema}}/{{.Table}}.sql"},
+	} {
+		if _, err := s.getFiles(cfg, sqlData, tablesParams, []string{"queries"}, "public"); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestGeneratedFooter(t *testing.T) {
+	for _, tc := range []struct {
+		engine     engineType
+		sqlcEngine sqlcconfig.Engine
+	}{
+		{engine: EngineTypePostgres, sqlcEngine: sqlcconfig.EnginePostgreSQL},
+		{engine: EngineTypeSqlite, sqlcEngine: sqlcconfig.EngineSQLite},
+		{engine: EngineTypeMysql, sqlcEngine: sqlcconfig.EngineMySQL},
+	} {
+		t.Run(string(tc.engine), func(t *testing.T) {
+			schema := filepath.Join(t.TempDir(), "schema.sql")
+			if err := os.WriteFile(schema, []byte("CREATE TABLE books (id int PRIMARY KEY, name text NOT NULL);"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := compiler.NewCompiler(sqlcconfig.SQL{Engine: tc.sqlcEngine}, sqlcconfig.CombinedSettings{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.ParseCatalog([]string{schema}); err != nil {
+				t.Fatal(err)
+			}
+
+			p := processParams{
+				table:    "books",
+				metaData: tableMetaData{columns: []string{"id", "name"}, primaryColumns: []string{"id"}},
+				engine:   tc.engine,
+			}
+			sqlData := map[string][]generatedQueries{
+				"books": {
+					{method: "get", data: []byte(runProcess(t, (*crud).processGet, config.CrudParams{}, p))},
+					{method: "delete", data: []byte(runProcess(t, (*crud).processDelete, config.CrudParams{}, p))},
+				},
+			}
+
+			files, err := (&crud{}).getFiles(config.CrudParams{}, sqlData, config.Table{"books": {}}, []string{"queries"}, "public")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data := string(files["queries/books_gen.sql"])
+			footer := generatedMarker + "\n-- versions:\n--   pgxgen \n"
+			if !strings.HasSuffix(data, "\n\n"+footer) {
+				t.Errorf("unexpected footer:\n%s", data)
+			}
+
+			queries, err := c.ParseQueriesSource(data, opts.Parser{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(queries) != 2 {
+				t.Fatalf("expected 2 queries, got %d", len(queries))
+			}
+			for _, query := range queries {
+				if len(query.Metadata.Comments) > 0 {
+					t.Errorf("unexpected comments of %s: %q", query.Metadata.Name, query.Metadata.Comments)
+				}
+			}
+		})
+	}
+}
+
+func TestDescription(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fn       processFunc
+		method   config.Method
+		table    config.TableParams
+		comment  string
+		expected string
+	}{
+		{
+			name:     "method description",
+			fn:       (*crud).processGet,
+			method:   config.Method{Description: "GetUser returns user by id"},
+			table:    config.TableParams{Description: "Registered users"},
+			comment:  "users of the service",
+			expected: "-- GetUser returns user by id\n-- name: GetUser :one\nSELECT * FROM users WHERE id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name:     "multiline description",
+			fn:       (*crud).processGet,
+			method:   config.Method{Description: "GetUser returns user by id.\n\nDeleted users are not returned.\n"},
+			expected: "-- GetUser returns user by id.\n--\n-- Deleted users are not returned.\n-- name: GetUser :one\nSELECT * FROM users WHERE id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name:     "table description",
+			fn:       (*crud).processGet,
+			table:    config.TableParams{Description: "Registered users"},
+			comment:  "users of the service",
+			expected: "-- Registered users\n-- name: GetUser :one\nSELECT * FROM users WHERE id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name:     "table comment",
+			fn:       (*crud).processGet,
+			comment:  "users of the service",
+			expected: "-- users of the service\n-- name: GetUser :one\nSELECT * FROM users WHERE id=$1 LIMIT 1;\n\n",
+		},
+		{
+			name:     "exists",
+			fn:       (*crud).processExists,
+			method:   config.Method{Description: "ExistsUser checks user by email", Where: map[string]config.WhereParamsItem{"email": {}}},
+			expected: "-- ExistsUser checks user by email\n-- name: ExistsUser :one\nSELECT EXISTS (SELECT 1 FROM users WHERE email=$1 LIMIT 1)::boolean;\n\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := runProcess(t, tc.fn, config.CrudParams{}, processParams{
+				table: "users",
+				metaData: tableMetaData{
+					columns:        []string{"id", "email"},
+					primaryColumns: []string{"id"},
+					comment:        tc.comment,
+				},
+				methodParams: tc.method,
+				tableParams:  tc.table,
+			})
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestManyToMany(t *testing.T) {
+	data := tables{
+		"books": {name: "books", columns: []string{"id", "name", "deleted_at"}, primaryColumns: []string{"id"}},
+		"tags":  {name: "tags", columns: []string{"id", "name"}, primaryColumns: []string{"id"}},
+	}
+
+	metaData := tableMetaData{
+		name:           "book_tags",
+		columns:        []string{"book_id", "tag_id"},
+		primaryColumns: []string{"book_id", "tag_id"},
+		foreignKeys: []foreignKey{
+			{columns: []string{"book_id"}, refTable: "books", refColumns: []string{"id"}},
+			// references the primary key
+			{columns: []string{"tag_id"}, refTable: "tags"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name         string
+		params       config.ManyToManyParams
+		tablesParams config.Table
+		engine       engineType
+		namedParams  bool
+		expected     string
+		err          bool
+	}{
+		{
+			name:   "postgres",
+			params: config.ManyToManyParams{Column: "book_id", RelatedColumn: "tag_id"},
+			expected: "-- name: AttachTagToBook :exec\nINSERT INTO book_tags (book_id, tag_id)\n\tVALUES ($1, $2)\n\tON CONFLICT DO NOTHING;\n\n" +
+				"-- name: DetachTagFromBook :exec\nDELETE FROM book_tags WHERE book_id=$1 AND tag_id=$2;\n\n" +
+				"-- name: FindTagsByBook :many\nSELECT tags.* FROM tags\n\tJOIN book_tags ON book_tags.tag_id=tags.id\n\tWHERE book_tags.book_id=$1;\n\n" +
+				"-- name: FindBooksByTag :many\nSELECT books.* FROM books\n\tJOIN book_tags ON book_tags.book_id=books.id\n\tWHERE book_tags.tag_id=$1;\n\n" +
+				"-- name: DeleteBookTags :exec\nDELETE FROM book_tags WHERE book_id=$1;\n\n",
+		},
+		{
+			name:   "soft delete of related table",
+			params: config.ManyToManyParams{Column: "tag_id", RelatedColumn: "book_id"},
+			tablesParams: config.Table{
+				"books": {SoftDelete: config.SoftDeleteParams{Column: "deleted_at"}},
+			},
+			namedParams: true,
+			expected: "-- name: AttachBookToTag :exec\nINSERT INTO book_tags (tag_id, book_id)\n\tVALUES (sqlc.arg('tag_id'), sqlc.arg('book_id'))\n\tON CONFLICT DO NOTHING;\n\n" +
+				"-- name: DetachBookFromTag :exec\nDELETE FROM book_tags WHERE book_id=sqlc.arg('book_id') AND tag_id=sqlc.arg('tag_id');\n\n" +
+				"-- name: FindBooksByTag :many\nSELECT books.* FROM books\n\tJOIN book_tags ON book_tags.book_id=books.id\n\tWHERE book_tags.tag_id=sqlc.arg('tag_id') AND books.deleted_at IS NULL;\n\n" +
+				"-- name: FindTagsByBook :many\nSELECT tags.* FROM tags\n\tJOIN book_tags ON book_tags.tag_id=tags.id\n\tWHERE book_tags.book_id=sqlc.arg('book_id');\n\n" +
+				"-- name: DeleteTagBooks :exec\nDELETE FROM book_tags WHERE tag_id=sqlc.arg('tag_id');\n\n",
+		},
+		{
+			name:   "mysql",
+			params: config.ManyToManyParams{Column: "book_id", RelatedColumn: "tag_id"},
+			engine: EngineTypeMysql,
+			expected: "-- name: AttachTagToBook :exec\nINSERT INTO book_tags (book_id, tag_id)\n\tVALUES (?, ?)\n\tON DUPLICATE KEY UPDATE book_id=book_id;\n\n" +
+				"-- name: DetachTagFromBook :exec\nDELETE FROM book_tags WHERE book_id=? AND tag_id=?;\n\n" +
+				"-- name: FindTagsByBook :many\nSELECT tags.* FROM tags\n\tJOIN book_tags ON book_tags.tag_id=tags.id\n\tWHERE book_tags.book_id=?;\n\n" +
+				"-- name: FindBooksByTag :many\nSELECT books.* FROM books\n\tJOIN book_tags ON book_tags.book_id=books.id\n\tWHERE book_tags.tag_id=?;\n\n" +
+				"-- name: DeleteBookTags :exec\nDELETE FROM book_tags WHERE book_id=?;\n\n",
+		},
+		{
+			name:   "not a foreign key",
+			params: config.ManyToManyParams{Column: "book_id", RelatedColumn: "id"},
+			err:    true,
+		},
+		{
+			name:   "required columns",
+			params: config.ManyToManyParams{Column: "book_id"},
+			err:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := processParams{
+				builder:     new(strings.Builder),
+				table:       "book_tags",
+				metaData:    metaData,
+				tableParams: config.TableParams{ManyToMany: tc.params},
+				engine:      tc.engine,
+				namedParams: tc.namedParams,
+			}
+			if p.engine == "" {
+				p.engine = EngineTypePostgres
+			}
+
+			err := (&crud{}).processManyToMany(config.CrudParams{}, p, data, tc.tablesParams)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.expected, p.builder.String()); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTree(t *testing.T) {
+	metaData := tableMetaData{
+		columns:        []string{"id", "parent_id", "name", "position", "deleted_at"},
+		primaryColumns: []string{"id"},
+		foreignKeys: []foreignKey{
+			{columns: []string{"parent_id"}, refTable: "categories", refColumns: []string{"id"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		metaData tableMetaData
+		method   config.Method
+		table    config.TableParams
+		engine   engineType
+		expected string
+		err      bool
+	}{
+		{
+			name:     "soft delete and order",
+			metaData: metaData,
+			method:   config.Method{Order: config.OrderParam{By: "position"}},
+			table:    config.TableParams{SoftDelete: config.SoftDeleteParams{Column: "deleted_at"}},
+			expected: "-- name: FindCategoryDescendants :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id') AND t.deleted_at IS NULL\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.parent_id=categories_tree.id\n\t\tWHERE t.deleted_at IS NULL\n)\nSELECT * FROM categories_tree WHERE depth > 0 ORDER BY depth, position ASC;\n\n" +
+				"-- name: FindCategoryAncestors :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id') AND t.deleted_at IS NULL\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.id=categories_tree.parent_id\n\t\tWHERE t.deleted_at IS NULL\n)\nSELECT * FROM categories_tree WHERE depth > 0 ORDER BY depth, position ASC;\n\n" +
+				"-- name: FindCategorySubtree :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id') AND t.deleted_at IS NULL\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.parent_id=categories_tree.id\n\t\tWHERE t.deleted_at IS NULL AND categories_tree.depth < sqlc.arg('max_depth')::integer\n)\nSELECT * FROM categories_tree ORDER BY depth, position ASC;\n\n",
+		},
+		{
+			name: "parent column without foreign key",
+			metaData: tableMetaData{
+				columns:        []string{"id", "parent_id", "name"},
+				primaryColumns: []string{"id"},
+			},
+			method: config.Method{ParentColumn: "parent_id"},
+			engine: EngineTypeMysql,
+			expected: "-- name: FindCategoryDescendants :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id')\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.parent_id=categories_tree.id\n)\nSELECT * FROM categories_tree WHERE depth > 0 ORDER BY depth;\n\n" +
+				"-- name: FindCategoryAncestors :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id')\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.id=categories_tree.parent_id\n)\nSELECT * FROM categories_tree WHERE depth > 0 ORDER BY depth;\n\n" +
+				"-- name: FindCategorySubtree :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id')\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.parent_id=categories_tree.id\n\t\tWHERE categories_tree.depth < CAST(sqlc.arg('max_depth') AS SIGNED)\n)\nSELECT * FROM categories_tree ORDER BY depth;\n\n",
+		},
+		{
+			name:     "sqlite",
+			metaData: metaData,
+			engine:   EngineTypeSqlite,
+			expected: "-- name: FindCategoryDescendants :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id')\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.parent_id=categories_tree.id\n)\nSELECT * FROM categories_tree WHERE depth > 0 ORDER BY depth;\n\n" +
+				"-- name: FindCategoryAncestors :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id')\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.id=categories_tree.parent_id\n)\nSELECT * FROM categories_tree WHERE depth > 0 ORDER BY depth;\n\n" +
+				"-- name: FindCategorySubtree :many\nWITH RECURSIVE categories_tree AS (\n\tSELECT t.*, 0 AS depth FROM categories t\n\t\tWHERE t.id=sqlc.arg('id')\n\tUNION ALL\n\tSELECT t.*, categories_tree.depth+1 FROM categories t\n\t\tJOIN categories_tree ON t.parent_id=categories_tree.id\n\t\tWHERE categories_tree.depth < CAST(sqlc.arg('max_depth') AS INTEGER)\n)\nSELECT * FROM categories_tree ORDER BY depth;\n\n",
+		},
+		{
+			name: "undefined parent column",
+			metaData: tableMetaData{
+				columns:        []string{"id", "name"},
+				primaryColumns: []string{"id"},
+			},
+			err: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := processParams{
+				builder:      new(strings.Builder),
+				table:        "categories",
+				metaData:     tc.metaData,
+				methodParams: tc.method,
+				tableParams:  tc.table,
+				engine:       tc.engine,
+			}
+			if p.engine == "" {
+				p.engine = EngineTypePostgres
+			}
+
+			err := (&crud{}).processTree(config.CrudParams{}, p)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.expected, p.builder.String()); diff != "" {
+				t.Errorf("sql mismatch:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTreeSchema(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(schema, []byte("CREATE SCHEMA billing;\nCREATE TABLE billing.invoices (id bigint PRIMARY KEY, parent_id bigint REFERENCES billing.invoices (id), deleted_at timestamptz);"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := compiler.NewCompiler(sqlcconfig.SQL{Engine: sqlcconfig.EnginePostgreSQL}, sqlcconfig.CombinedSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ParseCatalog([]string{schema}); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := runProcess(t, (*crud).processTree, config.CrudParams{}, processParams{
+		table: "billing.invoices",
+		metaData: tableMetaData{
+			columns:        []string{"id", "parent_id", "deleted_at"},
+			primaryColumns: []string{"id"},
+			foreignKeys: []foreignKey{
+				{columns: []string{"parent_id"}, refTable: "billing.invoices", refColumns: []string{"id"}},
+			},
+		},
+		tableParams: config.TableParams{SoftDelete: config.SoftDeleteParams{Column: "deleted_at"}},
+	})
+	if !strings.Contains(queries, "SELECT t.*, 0 AS depth FROM billing.invoices t\n\t\tWHERE t.id=sqlc.arg('id') AND t.deleted_at IS NULL\n") {
+		t.Errorf("unexpected queries:\n%s", queries)
+	}
+	if err := checkQueries(c, queries); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSerialColumnTypes(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(schema, []byte("CREATE TABLE books (id bigserial PRIMARY KEY, position serial NOT NULL, name text NOT NULL);"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := compiler.NewCompiler(sqlcconfig.SQL{Engine: sqlcconfig.EnginePostgreSQL}, sqlcconfig.CombinedSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ParseCatalog([]string{schema}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &crud{catalogs: map[string]cmd.GetCatalogResultItem{"repo": {Catalog: c.Catalog()}}}
+	data, err := s.getTableMeta("repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTypes := map[string]string{"id": "bigint", "position": "integer", "name": "text"}
+	if diff := cmp.Diff(expectedTypes, data["books"].columnTypes); diff != "" {
+		t.Errorf("column types mismatch:\n%s", diff)
+	}
+
+	actual := runProcess(t, (*crud).processGetMany, config.CrudParams{}, processParams{
+		table:    "books",
+		metaData: *data["books"],
+	})
+
+	expected := "-- name: GetManyBooks :many\nSELECT * FROM books WHERE id = ANY(sqlc.arg('id')::bigint[]);\n\n"
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("sql mismatch:\n%s", diff)
+	}
+}
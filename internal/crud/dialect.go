@@ -0,0 +1,156 @@
+package crud
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tkcrm/pgxgen/pkg/sqlc/engine/dolphin"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/engine/postgresql"
+	"github.com/tkcrm/pgxgen/pkg/sqlc/engine/sqlite"
+)
+
+// dialect describes sql syntax of the engine
+type dialect struct {
+	// identifier quote character
+	quote string
+	// reports whether the word is a reserved keyword of the engine
+	isReserved func(s string) bool
+	// positional params are numbered: $1, $2. Otherwise ?
+	numberedParams bool
+	// RETURNING clause is supported
+	returning bool
+	// ILIKE operator is supported, otherwise LIKE is case insensitive
+	ilike bool
+	// array params are supported: ANY($1::type[]). Otherwise sqlc.slice is used
+	arrays bool
+	// INSERT ... ON DUPLICATE KEY UPDATE instead of ON CONFLICT
+	onDuplicateKey bool
+	// sqlc.arg is supported in LIMIT and OFFSET
+	namedLimit bool
+	// positional params can be combined with named params
+	mixedParams bool
+	// :copyfrom is supported
+	copyFrom bool
+	// :batchone and :batchexec are supported
+	batch bool
+	// FOR UPDATE, FOR SHARE, SKIP LOCKED and NOWAIT are supported
+	locking bool
+	// current time for soft delete column
+	currentTimestamp string
+	// format of boolean cast, so sqlc generates bool type
+	booleanCast string
+	// format of integer cast, so sqlc infers type of params compared with computed columns
+	integerCast string
+}
+
+var dialects = map[engineType]dialect{
+	EngineTypePostgres: {
+		quote:            `"`,
+		isReserved:       postgresql.NewParser().IsReservedKeyword,
+		numberedParams:   true,
+		returning:        true,
+		ilike:            true,
+		arrays:           true,
+		namedLimit:       true,
+		mixedParams:      true,
+		copyFrom:         true,
+		batch:            true,
+		locking:          true,
+		currentTimestamp: "now()",
+		booleanCast:      "%s::boolean",
+		integerCast:      "%s::integer",
+	},
+	EngineTypeMysql: {
+		quote:            "`",
+		isReserved:       dolphin.NewParser().IsReservedKeyword,
+		onDuplicateKey:   true,
+		mixedParams:      true,
+		copyFrom:         true,
+		locking:          true,
+		currentTimestamp: "now()",
+		// EXISTS is inferred as boolean
+		booleanCast: "%s",
+		integerCast: "CAST(%s AS SIGNED)",
+	},
+	EngineTypeSqlite: {
+		quote:            `"`,
+		isReserved:       sqlite.NewParser().IsReservedKeyword,
+		returning:        true,
+		namedLimit:       true,
+		currentTimestamp: "CURRENT_TIMESTAMP",
+		booleanCast:      "CAST(%s AS BOOLEAN)",
+		integerCast:      "CAST(%s AS INTEGER)",
+	},
+}
+
+// dialect returns sql syntax of the engine
+func (p processParams) dialect() (dialect, error) {
+	d, ok := dialects[p.engine]
+	if !ok {
+		return dialect{}, fmt.Errorf("engine %s is not supported", p.engine)
+	}
+
+	return d, nil
+}
+
+// plainIdent matches identifiers which can be used without quotes
+var plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// ident returns identifier quoted if it is a reserved keyword or contains special characters.
+// Schema qualified names are quoted by parts: billing."user"
+func (d dialect) ident(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if plainIdent.MatchString(part) && !d.isReserved(part) {
+			continue
+		}
+		parts[i] = d.quote + strings.ReplaceAll(part, d.quote, d.quote+d.quote) + d.quote
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// idents returns comma separated identifiers
+func (d dialect) idents(names []string) string {
+	res := make([]string, len(names))
+	for i, name := range names {
+		res[i] = d.ident(name)
+	}
+
+	return strings.Join(res, ", ")
+}
+
+// placeholder returns positional param with the index
+func (d dialect) placeholder(index int) string {
+	if d.numberedParams {
+		return fmt.Sprintf("$%d", index)
+	}
+
+	return "?"
+}
+
+// limitOffset returns LIMIT and OFFSET clause.
+// Named params are used if the engine supports them, otherwise positional params starting from the index
+func (d dialect) limitOffset(named, offset bool, index int) string {
+	limitParam, offsetParam := d.placeholder(index), d.placeholder(index+1)
+	if named && d.namedLimit {
+		limitParam, offsetParam = "sqlc.arg('limit')", "sqlc.arg('offset')"
+	}
+
+	if !offset {
+		return " LIMIT " + limitParam
+	}
+
+	return " LIMIT " + limitParam + " OFFSET " + offsetParam
+}
+
+// boolean returns expression casted to boolean
+func (d dialect) boolean(expr string) string {
+	return fmt.Sprintf(d.booleanCast, expr)
+}
+
+// integer returns expression casted to integer
+func (d dialect) integer(expr string) string {
+	return fmt.Sprintf(d.integerCast, expr)
+}
@@ -4,5 +4,6 @@ import "fmt"
 
 var (
 	ErrUndefinedPrimaryColumn = fmt.Errorf("undefined primary column")
+	ErrDerivedMethod          = fmt.Errorf("method is generated by delete method with soft_delete and can not be configured")
 	ErrWhileProcessTemplate   = "error while process \"%s\" method for table \"%s\""
 )
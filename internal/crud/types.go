@@ -15,12 +15,139 @@ const (
 	METHOD_FIND   config.MethodType = "find"
 	METHOD_TOTAL  config.MethodType = "total"
 	METHOD_EXISTS config.MethodType = "exists"
+	METHOD_UPSERT config.MethodType = "upsert"
+	METHOD_PATCH  config.MethodType = "patch"
+
+	// atomic counter method
+	METHOD_INCREMENT config.MethodType = "increment"
+
+	// job queue method
+	METHOD_DEQUEUE config.MethodType = "dequeue"
+
+	// recursive methods of self-referencing table: descendants, ancestors and subtree
+	METHOD_TREE config.MethodType = "tree"
+
+	// copyfrom and batch methods
+	METHOD_CREATE_BULK  config.MethodType = "create_bulk"
+	METHOD_BATCH_GET    config.MethodType = "batch_get"
+	METHOD_BATCH_UPDATE config.MethodType = "batch_update"
+	METHOD_BATCH_DELETE config.MethodType = "batch_delete"
+
+	// methods for many rows by primary key array
+	METHOD_GET_MANY            config.MethodType = "get_many"
+	METHOD_DELETE_MANY         config.MethodType = "delete_many"
+	METHOD_UPDATE_MANY_COLUMNS config.MethodType = "update_many_columns"
+
+	// soft delete methods
+	METHOD_RESTORE     config.MethodType = "restore"
+	METHOD_HARD_DELETE config.MethodType = "hard_delete"
+
+	// relation methods
+	METHOD_FIND_BY   config.MethodType = "find_by"
+	METHOD_TOTAL_BY  config.MethodType = "total_by"
+	METHOD_DELETE_BY config.MethodType = "delete_by"
+)
+
+// allMethods are methods generated for METHOD_ALL
+var allMethods = []config.MethodType{
+	METHOD_CREATE,
+	METHOD_UPDATE,
+	METHOD_DELETE,
+	METHOD_GET,
+	METHOD_FIND,
+	METHOD_TOTAL,
+	METHOD_EXISTS,
+}
+
+const (
+	WHERE_OPERATOR_IN      = "in"
+	WHERE_OPERATOR_BETWEEN = "between"
+	WHERE_OPERATOR_ILIKE   = "ilike"
 )
 
+// whereOperators maps named operators to sql operators
+var whereOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "!=",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"like": " LIKE ",
+}
+
+const (
+	PAGINATION_OFFSET = "offset"
+	PAGINATION_KEYSET = "keyset"
+)
+
+// layouts of generated files
+const (
+	// one file for each table
+	LAYOUT_TABLE = "table"
+	// one file for each method of the table
+	LAYOUT_METHOD = "method"
+	// one file for all tables of the schema
+	LAYOUT_SCHEMA = "schema"
+)
+
+// generatedMarker is the first line of the footer of generated files.
+// The footer is written after the last query, so sqlc does not add it to comments of the first query
+const generatedMarker = "-- Code generated by pgxgen. DO NOT EDIT."
+
+// generatedQueries are generated queries of the method
+type generatedQueries struct {
+	method string
+	data   []byte
+}
+
+// fileNameData is data of file_name_template
+type fileNameData struct {
+	Schema string
+	Table  string
+	// Method type. Only for method layout
+	Method string
+}
+
 type tables map[string]*tableMetaData
 
 type tableMetaData struct {
+	// table name for method names. Includes the schema
+	// if tables with the same name exist in different schemas
+	name           string
+	columns        []string
+	columnTypes    map[string]string
+	primaryColumns []string
+	uniqueKeys     []uniqueKey
+	foreignKeys    []foreignKey
+	// table comment from the schema
+	comment string
+}
+
+// foreignKey is a set of columns which references another table
+type foreignKey struct {
 	columns []string
+	// referenced table. Tables of other schemas are qualified by the schema name
+	refTable   string
+	refColumns []string
+}
+
+// manyToManySide is the table referenced by the foreign key of the link table
+type manyToManySide struct {
+	// foreign key column of the link table
+	column    string
+	table     string
+	refColumn string
+	// table name for method names
+	name             string
+	softDeleteColumn string
+}
+
+// uniqueKey is a set of columns declared by unique constraint or unique index
+type uniqueKey struct {
+	columns []string
+	// predicate of the partial index
+	where string
 }
 
 func (t tables) getTableMetaData(tableName string) *tableMetaData {
@@ -59,4 +186,18 @@ type processParams struct {
 	methodParams config.Method
 	tableParams  config.TableParams
 	engine       engineType
+	// use sqlc.arg(column) instead of positional params
+	namedParams bool
+	// use batch query types: batchone, batchexec
+	batch bool
+	// delete rows even if soft delete is enabled
+	hardDelete bool
+}
+
+// tableName returns table name for method names
+func (p processParams) tableName() string {
+	if p.metaData.name != "" {
+		return p.metaData.name
+	}
+	return p.table
 }
@@ -86,6 +86,10 @@ func (s *goConstants) GenerateConstants() error {
 			}
 
 			for _, schema := range catalog.Catalog.Schemas {
+				if schema.Name != catalog.Catalog.DefaultSchema {
+					continue
+				}
+
 				for _, t := range schema.Tables {
 					if t.Rel.Name != tableName {
 						continue
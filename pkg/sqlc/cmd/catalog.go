@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sync"
 
 	"github.com/tkcrm/modules/pkg/utils"
@@ -26,10 +27,15 @@ type GetCatalogResultItem struct {
 	QueriesDir    []string
 	GoPackageName string
 	Catalog       *catalog.Catalog
+	// compiler with the parsed catalog. Used to validate queries
+	Compiler *compiler.Compiler
 }
 
 type GetCatalogResult []GetCatalogResultItem
 
+// systemSchemas are built-in schemas of the engines
+var systemSchemas = []string{"pg_catalog", "pg_temp", "information_schema"}
+
 func getConfigPathCustom(stderr io.Writer, filePath string) (string, string) {
 	if filePath != "" {
 		abspath, err := filepath.Abs(filePath)
@@ -145,8 +151,10 @@ func GetCatalogs(configFilePath string) (res GetCatalogResult, err error) {
 				return nil
 			}
 
-			c.Catalog().Schemas = utils.FilterArray(c.Catalog().Schemas, func(i *catalog.Schema) bool {
-				return i.Name == c.Catalog().DefaultSchema
+			// skip built-in schemas. The compiler keeps the full catalog
+			userCatalog := *c.Catalog()
+			userCatalog.Schemas = utils.FilterArray(userCatalog.Schemas, func(i *catalog.Schema) bool {
+				return i.Name == userCatalog.DefaultSchema || !slices.Contains(systemSchemas, i.Name)
 			})
 
 			item := GetCatalogResultItem{
@@ -154,7 +162,8 @@ func GetCatalogs(configFilePath string) (res GetCatalogResult, err error) {
 				SchemaDir:     sql.Schema,
 				QueriesDir:    sql.Queries,
 				GoPackageName: name,
-				Catalog:       c.Catalog(),
+				Catalog:       &userCatalog,
+				Compiler:      c,
 			}
 
 			m.Lock()
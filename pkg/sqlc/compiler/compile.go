@@ -118,3 +118,35 @@ func (c *Compiler) parseQueries(o opts.Parser) (*Result, error) {
 		Queries: q,
 	}, nil
 }
+
+// ParseQueriesSource parses and analyzes queries from the source against the catalog.
+// Errors of all queries are returned, the location of each error is relative to the source
+func (c *Compiler) ParseQueriesSource(src string, o opts.Parser) ([]*Query, error) {
+	stmts, err := c.parser.Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+
+	var q []*Query
+	merr := multierr.New()
+	for _, stmt := range stmts {
+		query, err := c.parseQuery(stmt.Raw, src, o)
+		if err != nil {
+			var e *sqlerr.Error
+			loc := stmt.Raw.Pos()
+			if errors.As(err, &e) && e.Location != 0 {
+				loc = e.Location
+			}
+			merr.Add("", src, loc, err)
+			continue
+		}
+		if query != nil {
+			q = append(q, query)
+		}
+	}
+	if len(merr.Errs()) > 0 {
+		return nil, merr
+	}
+
+	return q, nil
+}
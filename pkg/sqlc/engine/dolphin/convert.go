@@ -92,7 +92,15 @@ func (c *cc) convertAlterTableStmt(n *pcast.AlterTableStmt) ast.Node {
 			// 	spew.Dump("alter column", spec)
 
 		case pcast.AlterTableAddConstraint:
-			// 	spew.Dump("add const", spec)
+			if spec.Constraint == nil {
+				continue
+			}
+			if con := convertTableConstraint(spec.Constraint); con != nil {
+				alt.Cmds.Items = append(alt.Cmds.Items, &ast.AlterTableCmd{
+					Subtype:    ast.AT_AddConstraint,
+					Constraint: con,
+				})
+			}
 
 		case pcast.AlterTableRenameColumn:
 			// TODO: Returning here may be incorrect if there are multiple specs
@@ -220,6 +228,12 @@ func (c *cc) convertCreateTableStmt(n *pcast.CreateTableStmt) ast.Node {
 	}
 	for _, def := range n.Cols {
 		create.Cols = append(create.Cols, convertColumnDef(def))
+		create.Constraints = append(create.Constraints, columnConstraints(def)...)
+	}
+	for _, con := range n.Constraints {
+		if tc := convertTableConstraint(con); tc != nil {
+			create.Constraints = append(create.Constraints, tc)
+		}
 	}
 	for _, opt := range n.Options {
 		switch opt.Tp {
@@ -257,6 +271,15 @@ func convertColumnDef(def *pcast.ColumnDef) *ast.ColumnDef {
 		Comment:    comment,
 		Vals:       vals,
 	}
+	for _, opt := range def.Options {
+		switch opt.Tp {
+		case pcast.ColumnOptionPrimaryKey:
+			columnDef.PrimaryKey = true
+		case pcast.ColumnOptionDefaultValue:
+			columnDef.HasDefault = true
+			columnDef.Default = restoreExpr(opt.Expr)
+		}
+	}
 	if def.Tp.GetFlen() >= 0 {
 		length := def.Tp.GetFlen()
 		columnDef.Length = &length
@@ -767,7 +790,25 @@ func (c *cc) convertCreateDatabaseStmt(n *pcast.CreateDatabaseStmt) ast.Node {
 }
 
 func (c *cc) convertCreateIndexStmt(n *pcast.CreateIndexStmt) ast.Node {
-	return todo(n)
+	name := identifier(n.IndexName)
+	params := &ast.List{}
+	for _, part := range n.IndexPartSpecifications {
+		elem := &ast.IndexElem{}
+		if part.Column != nil {
+			colName := identifier(part.Column.Name.String())
+			elem.Name = &colName
+		} else {
+			elem.Expr = c.convert(part.Expr)
+		}
+		params.Items = append(params.Items, elem)
+	}
+	return &ast.IndexStmt{
+		Idxname:     &name,
+		Relation:    c.convertTableName(n.Table),
+		IndexParams: params,
+		Unique:      n.KeyType == pcast.IndexKeyTypeUnique,
+		IfNotExists: n.IfNotExists,
+	}
 }
 
 func (c *cc) convertCreateSequenceStmt(n *pcast.CreateSequenceStmt) ast.Node {
@@ -823,7 +864,14 @@ func (c *cc) convertDropDatabaseStmt(n *pcast.DropDatabaseStmt) ast.Node {
 }
 
 func (c *cc) convertDropIndexStmt(n *pcast.DropIndexStmt) ast.Node {
-	return todo(n)
+	table := parseTableName(n.Table)
+	return &ast.DropIndexStmt{
+		IfExists: n.IfExists,
+		Indexes: []*ast.TableName{
+			{Schema: table.Schema, Name: identifier(n.IndexName)},
+		},
+		Table: table,
+	}
 }
 
 func (c *cc) convertDropSequenceStmt(n *pcast.DropSequenceStmt) ast.Node {
@@ -1,7 +1,10 @@
 package dolphin
 
 import (
+	"strings"
+
 	pcast "github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
 	"github.com/pingcap/tidb/pkg/parser/mysql"
 
 	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/ast"
@@ -14,6 +17,83 @@ func parseTableName(n *pcast.TableName) *ast.TableName {
 	}
 }
 
+func restoreExpr(n pcast.ExprNode) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	ctx := format.NewRestoreCtx(format.RestoreStringSingleQuotes|format.RestoreKeyWordUppercase, &b)
+	if err := n.Restore(ctx); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+func indexPartNames(parts []*pcast.IndexPartSpecification) []string {
+	var names []string
+	for _, part := range parts {
+		if part.Column != nil {
+			names = append(names, identifier(part.Column.Name.String()))
+		}
+	}
+	return names
+}
+
+func convertReferenceDef(con *ast.TableConstraint, refer *pcast.ReferenceDef) {
+	if refer == nil {
+		return
+	}
+	if refer.Table != nil {
+		con.RefTable = parseTableName(refer.Table)
+	}
+	con.RefKeys = indexPartNames(refer.IndexPartSpecifications)
+}
+
+// convertTableConstraint converts PRIMARY KEY, UNIQUE and FOREIGN KEY constraints
+func convertTableConstraint(n *pcast.Constraint) *ast.TableConstraint {
+	con := &ast.TableConstraint{
+		Name: n.Name,
+		Keys: indexPartNames(n.Keys),
+	}
+	switch n.Tp {
+	case pcast.ConstraintPrimaryKey:
+		con.Contype = ast.TC_PrimaryKey
+	case pcast.ConstraintUniq, pcast.ConstraintUniqKey, pcast.ConstraintUniqIndex:
+		con.Contype = ast.TC_Unique
+	case pcast.ConstraintForeignKey:
+		con.Contype = ast.TC_ForeignKey
+		convertReferenceDef(con, n.Refer)
+	default:
+		return nil
+	}
+	return con
+}
+
+// columnConstraints returns key constraints declared in column options
+func columnConstraints(def *pcast.ColumnDef) []*ast.TableConstraint {
+	var res []*ast.TableConstraint
+	name := identifier(def.Name.String())
+	for _, opt := range def.Options {
+		con := &ast.TableConstraint{
+			Name: opt.ConstraintName,
+			Keys: []string{name},
+		}
+		switch opt.Tp {
+		case pcast.ColumnOptionPrimaryKey:
+			con.Contype = ast.TC_PrimaryKey
+		case pcast.ColumnOptionUniqKey:
+			con.Contype = ast.TC_Unique
+		case pcast.ColumnOptionReference:
+			con.Contype = ast.TC_ForeignKey
+			convertReferenceDef(con, opt.Refer)
+		default:
+			continue
+		}
+		res = append(res, con)
+	}
+	return res
+}
+
 func toList(node pcast.Node) *ast.List {
 	var items []ast.Node
 	switch n := node.(type) {
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/catalog"
 	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/sqlerr"
 
 	"github.com/google/go-cmp/cmp"
@@ -168,3 +169,282 @@ func TestUpdateErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateConstraints(t *testing.T) {
+	p := NewParser()
+	stmts, err := p.Parse(strings.NewReader(`
+		CREATE TABLE authors (
+			id UUID NOT NULL PRIMARY KEY DEFAULT uuid_generate_v4(),
+			email TEXT NOT NULL UNIQUE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE book_authors (
+			book_id INT NOT NULL,
+			author_id UUID NOT NULL REFERENCES authors,
+			PRIMARY KEY (book_id, author_id)
+		);
+		ALTER TABLE book_authors ADD CONSTRAINT book_authors_email_fk FOREIGN KEY (book_id) REFERENCES authors (email);
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCatalog()
+	if err := c.Build(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	type column struct {
+		Name         string
+		IsPrimaryKey bool
+		IsUnique     bool
+		HasDefault   bool
+		Default      string
+	}
+
+	type foreignKey struct {
+		Name       string
+		Columns    []string
+		RefTable   string
+		RefColumns []string
+	}
+
+	type table struct {
+		PrimaryKey  []string
+		UniqueKeys  [][]string
+		ForeignKeys []foreignKey
+		Columns     []column
+	}
+
+	expected := map[string]table{
+		"authors": {
+			PrimaryKey: []string{"id"},
+			UniqueKeys: [][]string{{"email"}},
+			Columns: []column{
+				{Name: "id", IsPrimaryKey: true, IsUnique: true, HasDefault: true, Default: "uuid_generate_v4()"},
+				{Name: "email", IsUnique: true},
+				{Name: "created_at", HasDefault: true, Default: "CURRENT_TIMESTAMP"},
+			},
+		},
+		"book_authors": {
+			PrimaryKey: []string{"book_id", "author_id"},
+			ForeignKeys: []foreignKey{
+				{Columns: []string{"author_id"}, RefTable: "authors", RefColumns: []string{"id"}},
+				{Name: "book_authors_email_fk", Columns: []string{"book_id"}, RefTable: "authors", RefColumns: []string{"email"}},
+			},
+			Columns: []column{
+				{Name: "book_id", IsPrimaryKey: true},
+				{Name: "author_id", IsPrimaryKey: true},
+			},
+		},
+	}
+
+	actual := make(map[string]table)
+	for _, schema := range c.Schemas {
+		if schema.Name != c.DefaultSchema {
+			continue
+		}
+		for _, tbl := range schema.Tables {
+			res := table{
+				PrimaryKey: tbl.PrimaryKey,
+				UniqueKeys: tbl.UniqueKeys,
+			}
+			for _, fk := range tbl.ForeignKeys {
+				res.ForeignKeys = append(res.ForeignKeys, foreignKey{
+					Name:       fk.Name,
+					Columns:    fk.Columns,
+					RefTable:   fk.RefTable.Name,
+					RefColumns: fk.RefColumns,
+				})
+			}
+			for _, col := range tbl.Columns {
+				res.Columns = append(res.Columns, column{
+					Name:         col.Name,
+					IsPrimaryKey: col.IsPrimaryKey,
+					IsUnique:     col.IsUnique,
+					HasDefault:   col.HasDefault,
+					Default:      col.Default,
+				})
+			}
+			actual[tbl.Rel.Name] = res
+		}
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("constraints mismatch:\n%s", diff)
+	}
+}
+
+func TestInheritedConstraints(t *testing.T) {
+	p := NewParser()
+	stmts, err := p.Parse(strings.NewReader(`
+		CREATE TABLE events (
+			id BIGINT PRIMARY KEY,
+			code TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE TABLE click_events (
+			url TEXT NOT NULL,
+			PRIMARY KEY (code)
+		) INHERITS (events);
+		ALTER TABLE click_events ADD CONSTRAINT click_events_created_at_key UNIQUE (created_at);
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCatalog()
+	if err := c.Build(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	type column struct {
+		Name         string
+		IsPrimaryKey bool
+		IsUnique     bool
+	}
+
+	expected := map[string][]column{
+		"events": {
+			{Name: "id", IsPrimaryKey: true, IsUnique: true},
+			{Name: "code"},
+			{Name: "created_at"},
+		},
+		"click_events": {
+			{Name: "id"},
+			{Name: "code", IsPrimaryKey: true, IsUnique: true},
+			{Name: "created_at", IsUnique: true},
+			{Name: "url"},
+		},
+	}
+
+	actual := make(map[string][]column)
+	for _, schema := range c.Schemas {
+		for _, tbl := range schema.Tables {
+			if _, ok := expected[tbl.Rel.Name]; !ok {
+				continue
+			}
+			for _, col := range tbl.Columns {
+				actual[tbl.Rel.Name] = append(actual[tbl.Rel.Name], column{
+					Name:         col.Name,
+					IsPrimaryKey: col.IsPrimaryKey,
+					IsUnique:     col.IsUnique,
+				})
+			}
+		}
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("columns mismatch:\n%s", diff)
+	}
+}
+
+func TestUpdateIndexes(t *testing.T) {
+	p := NewParser()
+	stmts, err := p.Parse(strings.NewReader(`
+		CREATE TABLE authors (
+			id BIGSERIAL PRIMARY KEY,
+			email TEXT NOT NULL,
+			org_id INT NOT NULL,
+			login TEXT NOT NULL
+		);
+		CREATE UNIQUE INDEX authors_email_idx ON authors (email) WHERE email <> '';
+		CREATE UNIQUE INDEX authors_org_login_idx ON authors (org_id, login);
+		CREATE INDEX authors_lower_login_idx ON authors (lower(login));
+		CREATE INDEX authors_org_idx ON authors (org_id);
+		DROP INDEX authors_org_idx;
+		ALTER TABLE authors DROP COLUMN org_id;
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCatalog()
+	if err := c.Build(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*catalog.Index{
+		{Name: "authors_email_idx", Columns: []string{"email"}, Unique: true, Where: "email <> ''"},
+		{Name: "authors_lower_login_idx", Columns: []string{"lower(login)"}},
+	}
+
+	var actual []*catalog.Index
+	for _, schema := range c.Schemas {
+		for _, tbl := range schema.Tables {
+			if tbl.Rel.Name == "authors" {
+				actual = tbl.Indexes
+			}
+		}
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("indexes mismatch:\n%s", diff)
+	}
+}
+
+func TestIndexPredicates(t *testing.T) {
+	p := NewParser()
+	stmts, err := p.Parse(strings.NewReader(`
+		CREATE TABLE items (
+			id BIGSERIAL PRIMARY KEY,
+			code TEXT NOT NULL,
+			status TEXT,
+			archived BOOLEAN,
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE UNIQUE INDEX items_distinct_idx ON items (code) WHERE status IS DISTINCT FROM 'z';
+		CREATE UNIQUE INDEX items_and_idx ON items (code, status)
+			WHERE created_at > '2020-01-01' AND archived IS NOT TRUE;
+		CREATE UNIQUE INDEX items_not_idx ON items (lower(code)) WHERE NOT archived;
+		CREATE UNIQUE INDEX "items where idx" ON items (code, created_at) WHERE (status = 'where' OR status IS NULL)
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCatalog()
+	if err := c.Build(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*catalog.Index{
+		{Name: "items_distinct_idx", Columns: []string{"code"}, Unique: true, Where: "status IS DISTINCT FROM 'z'"},
+		{Name: "items_and_idx", Columns: []string{"code", "status"}, Unique: true, Where: "created_at > '2020-01-01' AND archived IS NOT TRUE"},
+		{Name: "items_not_idx", Columns: []string{"lower(code)"}, Unique: true, Where: "NOT archived"},
+		{Name: "items where idx", Columns: []string{"code", "created_at"}, Unique: true, Where: "(status = 'where' OR status IS NULL)"},
+	}
+
+	var actual []*catalog.Index
+	for _, schema := range c.Schemas {
+		for _, tbl := range schema.Tables {
+			if tbl.Rel.Name == "items" {
+				actual = tbl.Indexes
+			}
+		}
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("indexes mismatch:\n%s", diff)
+	}
+}
+
+func TestIndexPredicate(t *testing.T) {
+	for _, tc := range []struct {
+		stmt     string
+		expected string
+		ok       bool
+	}{
+		{stmt: "CREATE INDEX a ON t (b) WHERE c > 1", expected: "c > 1", ok: true},
+		{stmt: "CREATE INDEX a ON t (b) WHERE c > 1;", expected: "c > 1", ok: true},
+		{stmt: "CREATE INDEX a ON t ((CASE WHEN x THEN 1 END)) where y = 'WHERE' -- where\n", expected: "y = 'WHERE' -- where", ok: true},
+		{stmt: "CREATE INDEX a ON t (b) WHERE c = $$ where $$", expected: "c = $$ where $$", ok: true},
+		{stmt: "CREATE INDEX nowhere ON t (b)", ok: false},
+		{stmt: "CREATE INDEX a ON t (b) WHERE c = 'x", ok: false},
+	} {
+		actual, ok := indexPredicate(tc.stmt)
+		if actual != tc.expected || ok != tc.ok {
+			t.Errorf("%s: expected %q, %v, got %q, %v", tc.stmt, tc.expected, tc.ok, actual, ok)
+		}
+	}
+}
@@ -169,6 +169,13 @@ func (p *Parser) Parse(r io.Reader) ([]ast.Statement, error) {
 		if n == nil {
 			return nil, fmt.Errorf("unexpected nil node")
 		}
+		if index, ok := n.(*ast.IndexStmt); ok && index.WhereClause != nil {
+			// the predicate is kept as the original text, because formatting of
+			// the expression does not support all operators
+			if where, ok := indexPredicate(statementText(string(contents), int(raw.StmtLocation), int(raw.StmtLen))); ok {
+				index.WhereClause = &ast.String{Str: where}
+			}
+		}
 		stmts = append(stmts, ast.Statement{
 			Raw: &ast.RawStmt{
 				Stmt:         n,
@@ -322,6 +329,18 @@ func translate(node *nodes.Node) (ast.Node, error) {
 				case nodes.AlterTableType_AT_SetNotNull:
 					item.Subtype = ast.AT_SetNotNull
 
+				case nodes.AlterTableType_AT_AddConstraint:
+					d, ok := altercmd.Def.Node.(*nodes.Node_Constraint)
+					if !ok {
+						return nil, fmt.Errorf("expected alter table definition to be a Constraint")
+					}
+					con := parseTableConstraint(d.Constraint, "")
+					if con == nil {
+						continue
+					}
+					item.Subtype = ast.AT_AddConstraint
+					item.Constraint = con
+
 				default:
 					continue
 				}
@@ -422,6 +441,9 @@ func translate(node *nodes.Node) (ast.Node, error) {
 						primaryKey[key.Node.(*nodes.Node_String_).String_.Sval] = true
 					}
 				}
+				if con := parseTableConstraint(item.Constraint, ""); con != nil {
+					create.Constraints = append(create.Constraints, con)
+				}
 
 			case *nodes.Node_TableLikeClause:
 				rel := parseRelationFromRangeVar(item.TableLikeClause.Relation)
@@ -436,21 +458,34 @@ func translate(node *nodes.Node) (ast.Node, error) {
 					return nil, err
 				}
 
-				primary := false
+				def := &ast.ColumnDef{
+					Colname:   item.ColumnDef.Colname,
+					TypeName:  rel.TypeName(),
+					IsNotNull: isNotNull(item.ColumnDef) || primaryKey[item.ColumnDef.Colname],
+					IsArray:   isArray(item.ColumnDef.TypeName),
+					ArrayDims: len(item.ColumnDef.TypeName.ArrayBounds),
+				}
+
 				for _, con := range item.ColumnDef.Constraints {
-					if constraint, ok := con.Node.(*nodes.Node_Constraint); ok {
-						primary = constraint.Constraint.Contype == nodes.ConstrType_CONSTR_PRIMARY
+					constraint, ok := con.Node.(*nodes.Node_Constraint)
+					if !ok {
+						continue
+					}
+
+					switch constraint.Constraint.Contype {
+					case nodes.ConstrType_CONSTR_PRIMARY:
+						def.PrimaryKey = true
+					case nodes.ConstrType_CONSTR_DEFAULT:
+						def.HasDefault = true
+						def.Default = ast.Format(convertNode(constraint.Constraint.RawExpr))
+					}
+
+					if tc := parseTableConstraint(constraint.Constraint, def.Colname); tc != nil {
+						create.Constraints = append(create.Constraints, tc)
 					}
 				}
 
-				create.Cols = append(create.Cols, &ast.ColumnDef{
-					Colname:    item.ColumnDef.Colname,
-					TypeName:   rel.TypeName(),
-					IsNotNull:  isNotNull(item.ColumnDef) || primaryKey[item.ColumnDef.Colname],
-					IsArray:    isArray(item.ColumnDef.TypeName),
-					ArrayDims:  len(item.ColumnDef.TypeName.ArrayBounds),
-					PrimaryKey: primary,
-				})
+				create.Cols = append(create.Cols, def)
 			}
 		}
 		return create, nil
@@ -588,6 +623,19 @@ func translate(node *nodes.Node) (ast.Node, error) {
 			}
 			return drop, nil
 
+		case nodes.ObjectType_OBJECT_INDEX:
+			drop := &ast.DropIndexStmt{
+				IfExists: n.MissingOk,
+			}
+			for _, obj := range n.Objects {
+				name, err := parseRelation(obj)
+				if err != nil {
+					return nil, fmt.Errorf("nodes.DropStmt: INDEX: %w", err)
+				}
+				drop.Indexes = append(drop.Indexes, name.TableName())
+			}
+			return drop, nil
+
 		case nodes.ObjectType_OBJECT_TYPE:
 			drop := &ast.DropTypeStmt{
 				IfExists: n.MissingOk,
@@ -604,6 +652,14 @@ func translate(node *nodes.Node) (ast.Node, error) {
 		}
 		return nil, errSkip
 
+	case *nodes.Node_IndexStmt:
+		stmt := convertIndexStmt(inner.IndexStmt)
+		if inner.IndexStmt.WhereClause == nil {
+			// convertNode returns TODO for nil nodes
+			stmt.WhereClause = nil
+		}
+		return stmt, nil
+
 	case *nodes.Node_RenameStmt:
 		n := inner.RenameStmt
 		switch n.RenameType {
@@ -1,7 +1,12 @@
 package postgresql
 
 import (
+	"strings"
+	"unicode"
+
 	nodes "github.com/pganalyze/pg_query_go/v6"
+
+	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/ast"
 )
 
 func isArray(n *nodes.TypeName) bool {
@@ -29,6 +34,39 @@ func isNotNull(n *nodes.ColumnDef) bool {
 	return false
 }
 
+// parseTableConstraint converts PRIMARY KEY, UNIQUE and FOREIGN KEY constraints.
+// For column constraints the column name is used as the only key.
+func parseTableConstraint(n *nodes.Constraint, column string) *ast.TableConstraint {
+	con := &ast.TableConstraint{
+		Name:     n.Conname,
+		Location: int(n.Location),
+	}
+
+	switch n.Contype {
+	case nodes.ConstrType_CONSTR_PRIMARY:
+		con.Contype = ast.TC_PrimaryKey
+		con.Keys = stringSliceFromNodes(n.Keys)
+	case nodes.ConstrType_CONSTR_UNIQUE:
+		con.Contype = ast.TC_Unique
+		con.Keys = stringSliceFromNodes(n.Keys)
+	case nodes.ConstrType_CONSTR_FOREIGN:
+		con.Contype = ast.TC_ForeignKey
+		con.Keys = stringSliceFromNodes(n.FkAttrs)
+		con.RefKeys = stringSliceFromNodes(n.PkAttrs)
+		if n.Pktable != nil {
+			con.RefTable = parseRelationFromRangeVar(n.Pktable).TableName()
+		}
+	default:
+		return nil
+	}
+
+	if len(con.Keys) == 0 && column != "" {
+		con.Keys = []string{column}
+	}
+
+	return con
+}
+
 func IsNamedParamFunc(node *nodes.Node) bool {
 	fun, ok := node.Node.(*nodes.Node_FuncCall)
 	return ok && joinNodes(fun.FuncCall.Funcname, ".") == "sqlc.arg"
@@ -61,3 +99,93 @@ func makeString(s string) *string {
 	}
 	return &s
 }
+
+// statementText returns the text of the statement. Zero length means the rest of the input
+func statementText(contents string, location, length int) string {
+	if location < 0 || location > len(contents) {
+		return ""
+	}
+	if length <= 0 || location+length > len(contents) {
+		return contents[location:]
+	}
+	return contents[location : location+length]
+}
+
+// indexPredicate returns the text of the WHERE clause of CREATE INDEX statement.
+// The predicate is the last clause of the statement, so it lasts until the end of the statement.
+// Keywords inside of parentheses, string literals, quoted identifiers and comments are skipped
+func indexPredicate(stmt string) (string, bool) {
+	start := -1
+	depth := 0
+	for i := 0; i < len(stmt); i++ {
+		switch c := stmt[i]; {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(stmt[i+1:], c)
+			if end < 0 {
+				return "", false
+			}
+			i += end + 1
+		case c == '$':
+			// dollar quoted string: $tag$ ... $tag$
+			tagEnd := strings.IndexByte(stmt[i+1:], '$')
+			if tagEnd < 0 || !isDollarTag(stmt[i+1:i+1+tagEnd]) {
+				continue
+			}
+			tag := stmt[i : i+tagEnd+2]
+			end := strings.Index(stmt[i+len(tag):], tag)
+			if end < 0 {
+				return "", false
+			}
+			i += len(tag) + end + len(tag) - 1
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			end := strings.IndexByte(stmt[i:], '\n')
+			if end < 0 {
+				end = len(stmt) - i
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return "", false
+			}
+			i += end + 3
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == 'w' || c == 'W') && isKeywordAt(stmt, i, "where"):
+			start = i + len("where")
+		}
+	}
+
+	if start < 0 {
+		return "", false
+	}
+
+	predicate := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt[start:]), ";"))
+	return predicate, predicate != ""
+}
+
+func isDollarTag(tag string) bool {
+	for i, r := range tag {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+// isKeywordAt reports whether the keyword is a separate word at position i
+func isKeywordAt(s string, i int, keyword string) bool {
+	if len(s) < i+len(keyword) || !strings.EqualFold(s[i:i+len(keyword)], keyword) {
+		return false
+	}
+	isWordChar := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	if i > 0 && isWordChar(s[i-1]) {
+		return false
+	}
+	end := i + len(keyword)
+	return end == len(s) || !isWordChar(s[end])
+}
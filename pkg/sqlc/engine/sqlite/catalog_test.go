@@ -230,6 +230,131 @@ func TestUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			`
+			CREATE TABLE foo (id integer PRIMARY KEY, bar text NOT NULL DEFAULT 'x' UNIQUE);
+			CREATE TABLE baz (foo_id integer REFERENCES foo (id), n integer, PRIMARY KEY (foo_id, n));
+			`,
+			&catalog.Schema{
+				Name: "main",
+				Tables: []*catalog.Table{
+					{
+						Rel:        &ast.TableName{Name: "foo"},
+						PrimaryKey: []string{"id"},
+						UniqueKeys: [][]string{{"bar"}},
+						Columns: []*catalog.Column{
+							{
+								Name:         "id",
+								Type:         ast.TypeName{Name: "integer"},
+								IsNotNull:    true,
+								IsPrimaryKey: true,
+								IsUnique:     true,
+							},
+							{
+								Name:       "bar",
+								Type:       ast.TypeName{Name: "text"},
+								IsNotNull:  true,
+								IsUnique:   true,
+								HasDefault: true,
+								Default:    "'x'",
+							},
+						},
+					},
+					{
+						Rel:        &ast.TableName{Name: "baz"},
+						PrimaryKey: []string{"foo_id", "n"},
+						ForeignKeys: []*catalog.ForeignKey{
+							{
+								Columns:    []string{"foo_id"},
+								RefTable:   &ast.TableName{Name: "foo"},
+								RefColumns: []string{"id"},
+							},
+						},
+						Columns: []*catalog.Column{
+							{
+								Name:         "foo_id",
+								Type:         ast.TypeName{Name: "integer"},
+								IsPrimaryKey: true,
+							},
+							{
+								Name:         "n",
+								Type:         ast.TypeName{Name: "integer"},
+								IsPrimaryKey: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+			CREATE TABLE foo (
+				created_at text DEFAULT (datetime('now', 'utc')),
+				status text DEFAULT ('new' || ' and ' || 'active')
+			);
+			`,
+			&catalog.Schema{
+				Name: "main",
+				Tables: []*catalog.Table{
+					{
+						Rel: &ast.TableName{Name: "foo"},
+						Columns: []*catalog.Column{
+							{
+								Name:       "created_at",
+								Type:       ast.TypeName{Name: "text"},
+								HasDefault: true,
+								Default:    "datetime('now', 'utc')",
+							},
+							{
+								Name:       "status",
+								Type:       ast.TypeName{Name: "text"},
+								HasDefault: true,
+								Default:    "'new' || ' and ' || 'active'",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+			CREATE TABLE foo (bar text, baz text);
+			CREATE UNIQUE INDEX foo_bar_idx ON foo (bar) WHERE bar <> '';
+			CREATE INDEX foo_baz_idx ON foo (baz);
+			CREATE INDEX foo_bar_baz_idx ON foo (bar, baz);
+			DROP INDEX foo_baz_idx;
+			`,
+			&catalog.Schema{
+				Name: "main",
+				Tables: []*catalog.Table{
+					{
+						Rel: &ast.TableName{Name: "foo"},
+						Columns: []*catalog.Column{
+							{
+								Name: "bar",
+								Type: ast.TypeName{Name: "text"},
+							},
+							{
+								Name: "baz",
+								Type: ast.TypeName{Name: "text"},
+							},
+						},
+						Indexes: []*catalog.Index{
+							{
+								Name:    "foo_bar_idx",
+								Columns: []string{"bar"},
+								Unique:  true,
+								Where:   "bar <> ''",
+							},
+							{
+								Name:    "foo_bar_baz_idx",
+								Columns: []string{"bar", "baz"},
+							},
+						},
+					},
+				},
+			},
+		},
 	} {
 		test := tc
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
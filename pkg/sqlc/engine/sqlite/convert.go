@@ -117,11 +117,23 @@ func (c *cc) convertCreate_table_stmtContext(n *parser.Create_table_stmtContext)
 			if def.Type_name() != nil {
 				typeName = def.Type_name().GetText()
 			}
+			colName := identifier(def.Column_name().GetText())
+			constraints, hasDefault, defaultValue := columnConstraints(colName, def.AllColumn_constraint())
 			stmt.Cols = append(stmt.Cols, &ast.ColumnDef{
-				Colname:   identifier(def.Column_name().GetText()),
-				IsNotNull: hasNotNullConstraint(def.AllColumn_constraint()),
-				TypeName:  &ast.TypeName{Name: typeName},
+				Colname:    colName,
+				IsNotNull:  hasNotNullConstraint(def.AllColumn_constraint()),
+				TypeName:   &ast.TypeName{Name: typeName},
+				HasDefault: hasDefault,
+				Default:    defaultValue,
 			})
+			stmt.Constraints = append(stmt.Constraints, constraints...)
+		}
+	}
+	for _, icon := range n.AllTable_constraint() {
+		if con, ok := icon.(*parser.Table_constraintContext); ok {
+			if tc := convertTableConstraint(con); tc != nil {
+				stmt.Constraints = append(stmt.Constraints, tc)
+			}
 		}
 	}
 	return stmt
@@ -251,7 +263,59 @@ func (c *cc) convertDelete_stmtContext(n Delete_stmt) ast.Node {
 	return todo("convertDelete_stmtContext", n)
 }
 
+func (c *cc) convertCreate_index_stmtContext(n *parser.Create_index_stmtContext) ast.Node {
+	name := identifier(n.Index_name().GetText())
+	relname := identifier(n.Table_name().GetText())
+	relation := &ast.RangeVar{Relname: &relname}
+	if n.Schema_name() != nil {
+		schema := n.Schema_name().GetText()
+		relation.Schemaname = &schema
+	}
+
+	params := &ast.List{}
+	for _, icol := range n.AllIndexed_column() {
+		col, ok := icol.(*parser.Indexed_columnContext)
+		if !ok {
+			continue
+		}
+		elem := &ast.IndexElem{}
+		if col.Column_name() != nil {
+			colName := identifier(col.Column_name().GetText())
+			elem.Name = &colName
+		} else if col.Expr() != nil {
+			elem.Expr = c.convert(col.Expr())
+		}
+		params.Items = append(params.Items, elem)
+	}
+
+	stmt := &ast.IndexStmt{
+		Idxname:     &name,
+		Relation:    relation,
+		IndexParams: params,
+		Unique:      n.UNIQUE_() != nil,
+		IfNotExists: n.EXISTS_() != nil,
+	}
+	if n.WHERE_() != nil && n.Expr() != nil {
+		stmt.WhereClause = &ast.String{Str: sourceText(n.Expr())}
+	}
+	return stmt
+}
+
 func (c *cc) convertDrop_stmtContext(n *parser.Drop_stmtContext) ast.Node {
+	if n.INDEX_() != nil {
+		name := ast.TableName{
+			Name: identifier(n.Any_name().GetText()),
+		}
+		if n.Schema_name() != nil {
+			name.Schema = n.Schema_name().GetText()
+		}
+
+		return &ast.DropIndexStmt{
+			IfExists: n.EXISTS_() != nil,
+			Indexes:  []*ast.TableName{&name},
+		}
+	}
+
 	if n.TABLE_() != nil || n.VIEW_() != nil {
 		name := ast.TableName{
 			Name: identifier(n.Any_name().GetText()),
@@ -1144,6 +1208,9 @@ func (c *cc) convert(node node) ast.Node {
 	case *parser.Attach_stmtContext:
 		return c.convertAttach_stmtContext(n)
 
+	case *parser.Create_index_stmtContext:
+		return c.convertCreate_index_stmtContext(n)
+
 	case *parser.Create_table_stmtContext:
 		return c.convertCreate_table_stmtContext(n)
 
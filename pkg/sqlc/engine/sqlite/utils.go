@@ -1,10 +1,22 @@
 package sqlite
 
 import (
+	"github.com/antlr4-go/antlr/v4"
+
 	"github.com/tkcrm/pgxgen/pkg/sqlc/engine/sqlite/parser"
 	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/ast"
 )
 
+// sourceText returns the original text of the rule, including whitespace
+// that GetText drops
+func sourceText(n antlr.ParserRuleContext) string {
+	start, stop := n.GetStart(), n.GetStop()
+	if start == nil || stop == nil {
+		return n.GetText()
+	}
+	return start.GetInputStream().GetTextFromInterval(antlr.NewInterval(start.GetStart(), stop.GetStop()))
+}
+
 type tableNamer interface {
 	Table_name() parser.ITable_nameContext
 	Schema_name() parser.ISchema_nameContext
@@ -35,3 +47,99 @@ func hasNotNullConstraint(checks []parser.IColumn_constraintContext) bool {
 	}
 	return false
 }
+
+func indexedColumnNames(cols []parser.IIndexed_columnContext) []string {
+	var names []string
+	for _, icol := range cols {
+		col, ok := icol.(*parser.Indexed_columnContext)
+		if !ok || col.Column_name() == nil {
+			continue
+		}
+		names = append(names, identifier(col.Column_name().GetText()))
+	}
+	return names
+}
+
+func columnNames(cols []parser.IColumn_nameContext) []string {
+	names := make([]string, 0, len(cols))
+	for _, col := range cols {
+		names = append(names, identifier(col.GetText()))
+	}
+	return names
+}
+
+func convertForeignKeyClause(con *ast.TableConstraint, clause parser.IForeign_key_clauseContext) {
+	fk, ok := clause.(*parser.Foreign_key_clauseContext)
+	if !ok {
+		return
+	}
+	if fk.Foreign_table() != nil {
+		con.RefTable = &ast.TableName{Name: identifier(fk.Foreign_table().GetText())}
+	}
+	con.RefKeys = columnNames(fk.AllColumn_name())
+}
+
+// columnConstraints returns key constraints and the default value declared for a column
+func columnConstraints(column string, checks []parser.IColumn_constraintContext) (res []*ast.TableConstraint, hasDefault bool, defaultValue string) {
+	for i := range checks {
+		constraint, ok := checks[i].(*parser.Column_constraintContext)
+		if !ok {
+			continue
+		}
+
+		con := &ast.TableConstraint{Keys: []string{column}}
+		if constraint.Name() != nil {
+			con.Name = identifier(constraint.Name().GetText())
+		}
+
+		switch {
+		case constraint.PRIMARY_() != nil && constraint.KEY_() != nil:
+			con.Contype = ast.TC_PrimaryKey
+		case constraint.UNIQUE_() != nil:
+			con.Contype = ast.TC_Unique
+		case constraint.Foreign_key_clause() != nil:
+			con.Contype = ast.TC_ForeignKey
+			convertForeignKeyClause(con, constraint.Foreign_key_clause())
+		case constraint.DEFAULT_() != nil:
+			hasDefault = true
+			switch {
+			case constraint.Signed_number() != nil:
+				defaultValue = constraint.Signed_number().GetText()
+			case constraint.Literal_value() != nil:
+				defaultValue = constraint.Literal_value().GetText()
+			case constraint.Expr() != nil:
+				defaultValue = sourceText(constraint.Expr())
+			}
+			continue
+		default:
+			continue
+		}
+
+		res = append(res, con)
+	}
+	return res, hasDefault, defaultValue
+}
+
+// convertTableConstraint converts PRIMARY KEY, UNIQUE and FOREIGN KEY table constraints
+func convertTableConstraint(n *parser.Table_constraintContext) *ast.TableConstraint {
+	con := &ast.TableConstraint{}
+	if n.Name() != nil {
+		con.Name = identifier(n.Name().GetText())
+	}
+
+	switch {
+	case n.PRIMARY_() != nil:
+		con.Contype = ast.TC_PrimaryKey
+		con.Keys = indexedColumnNames(n.AllIndexed_column())
+	case n.UNIQUE_() != nil:
+		con.Contype = ast.TC_Unique
+		con.Keys = indexedColumnNames(n.AllIndexed_column())
+	case n.FOREIGN_() != nil:
+		con.Contype = ast.TC_ForeignKey
+		con.Keys = columnNames(n.AllColumn_name())
+		convertForeignKeyClause(con, n.Foreign_key_clause())
+	default:
+		return nil
+	}
+	return con
+}
@@ -6,6 +6,7 @@ const (
 	AT_DropColumn
 	AT_DropNotNull
 	AT_SetNotNull
+	AT_AddConstraint
 )
 
 type AlterTableType int
@@ -22,18 +23,21 @@ func (t AlterTableType) String() string {
 		return "DropNotNull"
 	case AT_SetNotNull:
 		return "SetNotNull"
+	case AT_AddConstraint:
+		return "AddConstraint"
 	default:
 		return "Unknown"
 	}
 }
 
 type AlterTableCmd struct {
-	Subtype   AlterTableType
-	Name      *string
-	Def       *ColumnDef
-	Newowner  *RoleSpec
-	Behavior  DropBehavior
-	MissingOk bool
+	Subtype    AlterTableType
+	Name       *string
+	Def        *ColumnDef
+	Constraint *TableConstraint
+	Newowner   *RoleSpec
+	Behavior   DropBehavior
+	MissingOk  bool
 }
 
 func (n *AlterTableCmd) Pos() int {
@@ -49,6 +53,10 @@ func (n *AlterTableCmd) Format(buf *TrackedBuffer) {
 		buf.WriteString(" ADD COLUMN ")
 	case AT_DropColumn:
 		buf.WriteString(" DROP COLUMN ")
+	case AT_AddConstraint:
+		buf.WriteString(" ADD ")
+		buf.astFormat(n.Constraint)
+		return
 	}
 
 	buf.astFormat(n.Def)
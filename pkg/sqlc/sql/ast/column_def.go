@@ -10,6 +10,8 @@ type ColumnDef struct {
 	Vals       *List
 	Length     *int
 	PrimaryKey bool
+	HasDefault bool
+	Default    string
 
 	// From pg.ColumnDef
 	Inhcount      int
@@ -7,6 +7,7 @@ type CreateTableStmt struct {
 	ReferTable  *TableName
 	Comment     string
 	Inherits    []*TableName
+	Constraints []*TableConstraint
 }
 
 func (n *CreateTableStmt) Pos() int {
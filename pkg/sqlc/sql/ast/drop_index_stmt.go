@@ -0,0 +1,12 @@
+package ast
+
+type DropIndexStmt struct {
+	IfExists bool
+	Indexes  []*TableName
+	// Table is set for engines where index names are scoped by table
+	Table *TableName
+}
+
+func (n *DropIndexStmt) Pos() int {
+	return 0
+}
@@ -1,5 +1,11 @@
 package ast
 
+const (
+	_ NullTestType = iota
+	NullTestTypeIsNull
+	NullTestTypeIsNotNull
+)
+
 type NullTestType uint
 
 func (n *NullTestType) Pos() int {
@@ -1,5 +1,7 @@
 package ast
 
+import "strconv"
+
 type SQLValueFunction struct {
 	Xpr      Node
 	Op       SQLValueFunctionOp
@@ -19,19 +21,29 @@ func (n *SQLValueFunction) Format(buf *TrackedBuffer) {
 	switch n.Op {
 	case SVFOpCurrentDate:
 		buf.WriteString("CURRENT_DATE")
-	case SVFOpCurrentTime:
-	case SVFOpCurrentTimeN:
-	case SVFOpCurrentTimestamp:
-	case SVFOpCurrentTimestampN:
-	case SVFOpLocaltime:
-	case SVFOpLocaltimeN:
-	case SVFOpLocaltimestamp:
-	case SVFOpLocaltimestampN:
+	case SVFOpCurrentTime, SVFOpCurrentTimeN:
+		buf.WriteString("CURRENT_TIME")
+	case SVFOpCurrentTimestamp, SVFOpCurrentTimestampN:
+		buf.WriteString("CURRENT_TIMESTAMP")
+	case SVFOpLocaltime, SVFOpLocaltimeN:
+		buf.WriteString("LOCALTIME")
+	case SVFOpLocaltimestamp, SVFOpLocaltimestampN:
+		buf.WriteString("LOCALTIMESTAMP")
 	case SVFOpCurrentRole:
+		buf.WriteString("CURRENT_ROLE")
 	case SVFOpCurrentUser:
+		buf.WriteString("CURRENT_USER")
 	case SVFOpUser:
+		buf.WriteString("USER")
 	case SVFOpSessionUser:
+		buf.WriteString("SESSION_USER")
 	case SVFOpCurrentCatalog:
+		buf.WriteString("CURRENT_CATALOG")
 	case SVFOpCurrentSchema:
+		buf.WriteString("CURRENT_SCHEMA")
+	}
+	switch n.Op {
+	case SVFOpCurrentTimeN, SVFOpCurrentTimestampN, SVFOpLocaltimeN, SVFOpLocaltimestampN:
+		buf.WriteString("(" + strconv.Itoa(int(n.Typmod)) + ")")
 	}
 }
@@ -0,0 +1,70 @@
+package ast
+
+import "strings"
+
+const (
+	TC_PrimaryKey TableConstraintType = iota
+	TC_Unique
+	TC_ForeignKey
+)
+
+type TableConstraintType int
+
+func (t TableConstraintType) String() string {
+	switch t {
+	case TC_PrimaryKey:
+		return "PrimaryKey"
+	case TC_Unique:
+		return "Unique"
+	case TC_ForeignKey:
+		return "ForeignKey"
+	default:
+		return "Unknown"
+	}
+}
+
+// TableConstraint describes a PRIMARY KEY, UNIQUE or FOREIGN KEY constraint.
+// Column level constraints are normalized to a single key constraint.
+type TableConstraint struct {
+	Contype  TableConstraintType
+	Name     string
+	Keys     []string
+	RefTable *TableName
+	RefKeys  []string
+	Location int
+}
+
+func (n *TableConstraint) Pos() int {
+	return n.Location
+}
+
+func (n *TableConstraint) Format(buf *TrackedBuffer) {
+	if n == nil {
+		return
+	}
+	if n.Name != "" {
+		buf.WriteString("CONSTRAINT ")
+		buf.WriteString(n.Name)
+		buf.WriteString(" ")
+	}
+	switch n.Contype {
+	case TC_PrimaryKey:
+		buf.WriteString("PRIMARY KEY")
+	case TC_Unique:
+		buf.WriteString("UNIQUE")
+	case TC_ForeignKey:
+		buf.WriteString("FOREIGN KEY")
+	}
+	buf.WriteString(" (")
+	buf.WriteString(strings.Join(n.Keys, ", "))
+	buf.WriteString(")")
+	if n.Contype == TC_ForeignKey && n.RefTable != nil {
+		buf.WriteString(" REFERENCES ")
+		buf.astFormat(n.RefTable)
+		if len(n.RefKeys) > 0 {
+			buf.WriteString(" (")
+			buf.WriteString(strings.Join(n.RefKeys, ", "))
+			buf.WriteString(")")
+		}
+	}
+}
@@ -95,6 +95,9 @@ func Walk(f Visitor, node ast.Node) {
 	case *ast.DropFunctionStmt:
 		// pass
 
+	case *ast.DropIndexStmt:
+		// pass
+
 	case *ast.DropSchemaStmt:
 		// pass
 
@@ -151,6 +154,9 @@ func Walk(f Visitor, node ast.Node) {
 	case *ast.TODO:
 		// pass
 
+	case *ast.TableConstraint:
+		// pass
+
 	case *ast.TableName:
 		// pass
 
@@ -91,6 +91,9 @@ func (c *Catalog) Update(stmt ast.Statement, colGen columnGenerator) error {
 	case *ast.CreateFunctionStmt:
 		err = c.createFunction(n)
 
+	case *ast.IndexStmt:
+		err = c.createIndex(n)
+
 	case *ast.CreateSchemaStmt:
 		err = c.createSchema(n)
 
@@ -106,6 +109,9 @@ func (c *Catalog) Update(stmt ast.Statement, colGen columnGenerator) error {
 	case *ast.DropFunctionStmt:
 		err = c.dropFunction(n)
 
+	case *ast.DropIndexStmt:
+		err = c.dropIndex(n)
+
 	case *ast.DropSchemaStmt:
 		err = c.dropSchema(n)
 
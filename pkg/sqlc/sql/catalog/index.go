@@ -0,0 +1,95 @@
+package catalog
+
+import (
+	"slices"
+
+	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/ast"
+)
+
+// Index describes an index created on table columns
+//
+// Expression elements are stored in Columns as formatted expressions.
+// Where is the original text of the index predicate.
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+	Where   string
+}
+
+func (c *Catalog) createIndex(stmt *ast.IndexStmt) error {
+	if stmt.Relation == nil || stmt.Relation.Relname == nil {
+		return nil
+	}
+
+	rel := &ast.TableName{Name: *stmt.Relation.Relname}
+	if stmt.Relation.Schemaname != nil {
+		rel.Schema = *stmt.Relation.Schemaname
+	}
+
+	_, table, err := c.getTable(rel)
+	if err != nil {
+		// indexes on relations unknown to the catalog are ignored
+		return nil
+	}
+
+	index := &Index{
+		Unique: stmt.Unique,
+	}
+	if stmt.WhereClause != nil {
+		// parsers keep the original text of the predicate. Formatted expressions
+		// may lose operators, so indexes with such predicates are skipped
+		where, ok := stmt.WhereClause.(*ast.String)
+		if !ok || where.Str == "" {
+			return nil
+		}
+		index.Where = where.Str
+	}
+	if stmt.Idxname != nil {
+		index.Name = *stmt.Idxname
+	}
+
+	if index.Name != "" && slices.ContainsFunc(table.Indexes, func(i *Index) bool { return i.Name == index.Name }) {
+		return nil
+	}
+
+	if stmt.IndexParams != nil {
+		for _, item := range stmt.IndexParams.Items {
+			elem, ok := item.(*ast.IndexElem)
+			if !ok {
+				continue
+			}
+			if elem.Name != nil {
+				index.Columns = append(index.Columns, *elem.Name)
+			} else {
+				index.Columns = append(index.Columns, ast.Format(elem.Expr))
+			}
+		}
+	}
+
+	table.Indexes = append(table.Indexes, index)
+	return nil
+}
+
+func (c *Catalog) dropIndex(stmt *ast.DropIndexStmt) error {
+	for _, name := range stmt.Indexes {
+		ns := name.Schema
+		if ns == "" {
+			ns = c.DefaultSchema
+		}
+		schema, err := c.getSchema(ns)
+		if err != nil {
+			continue
+		}
+
+		for _, table := range schema.Tables {
+			if stmt.Table != nil && table.Rel.Name != stmt.Table.Name {
+				continue
+			}
+			table.Indexes = slices.DeleteFunc(table.Indexes, func(i *Index) bool {
+				return i.Name == name.Name
+			})
+		}
+	}
+	return nil
+}
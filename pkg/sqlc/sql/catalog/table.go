@@ -1,8 +1,10 @@
 package catalog
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/ast"
 	"github.com/tkcrm/pgxgen/pkg/sqlc/sql/sqlerr"
@@ -13,9 +15,132 @@ import (
 // A database table is a collection of related data held in a table format within a database.
 // It consists of columns and rows.
 type Table struct {
-	Rel     *ast.TableName
-	Columns []*Column
-	Comment string
+	Rel         *ast.TableName
+	Columns     []*Column
+	PrimaryKey  []string
+	UniqueKeys  [][]string
+	ForeignKeys []*ForeignKey
+	Indexes     []*Index
+	Comment     string
+}
+
+// ForeignKey describes a REFERENCES constraint between the columns of two tables
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   *ast.TableName
+	RefColumns []string
+}
+
+func (table *Table) getColumn(name string) *Column {
+	for _, c := range table.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// updateColumn changes the column of the table. Columns inherited from the parent
+// table are shared with it, so the column is copied before it is changed
+func (table *Table) updateColumn(name string, fn func(c *Column)) {
+	for i, c := range table.Columns {
+		if c.Name == name {
+			col := *c
+			fn(&col)
+			table.Columns[i] = &col
+			return
+		}
+	}
+}
+
+func (c *Catalog) addConstraint(table *Table, con *ast.TableConstraint) error {
+	for _, key := range con.Keys {
+		if table.getColumn(key) == nil {
+			return sqlerr.ColumnNotFound(table.Rel.Name, key)
+		}
+	}
+
+	switch con.Contype {
+	case ast.TC_PrimaryKey:
+		table.PrimaryKey = append([]string{}, con.Keys...)
+		for _, key := range con.Keys {
+			table.updateColumn(key, func(c *Column) { c.IsPrimaryKey = true })
+		}
+		if len(con.Keys) == 1 {
+			table.updateColumn(con.Keys[0], func(c *Column) { c.IsUnique = true })
+		}
+
+	case ast.TC_Unique:
+		table.UniqueKeys = append(table.UniqueKeys, append([]string{}, con.Keys...))
+		if len(con.Keys) == 1 {
+			table.updateColumn(con.Keys[0], func(c *Column) { c.IsUnique = true })
+		}
+
+	case ast.TC_ForeignKey:
+		fk := &ForeignKey{
+			Name:       con.Name,
+			Columns:    append([]string{}, con.Keys...),
+			RefTable:   con.RefTable,
+			RefColumns: append([]string{}, con.RefKeys...),
+		}
+		// REFERENCES without a column list points to the primary key
+		// of the referenced table
+		if len(fk.RefColumns) == 0 && fk.RefTable != nil {
+			if fk.RefTable.Name == table.Rel.Name && fk.RefTable.Schema == table.Rel.Schema {
+				fk.RefColumns = append([]string{}, table.PrimaryKey...)
+			} else if _, refTable, err := c.getTable(fk.RefTable); err == nil {
+				fk.RefColumns = append([]string{}, refTable.PrimaryKey...)
+			}
+		}
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	}
+
+	return nil
+}
+
+// dropColumnConstraints removes every key constraint that includes the column
+func (table *Table) dropColumnConstraints(name string) {
+	if slices.Contains(table.PrimaryKey, name) {
+		table.PrimaryKey = nil
+	}
+	table.UniqueKeys = slices.DeleteFunc(table.UniqueKeys, func(keys []string) bool {
+		return slices.Contains(keys, name)
+	})
+	table.ForeignKeys = slices.DeleteFunc(table.ForeignKeys, func(fk *ForeignKey) bool {
+		return slices.Contains(fk.Columns, name)
+	})
+	table.Indexes = slices.DeleteFunc(table.Indexes, func(index *Index) bool {
+		return slices.Contains(index.Columns, name)
+	})
+	for _, c := range table.Columns {
+		if isPrimaryKey := slices.Contains(table.PrimaryKey, c.Name); c.IsPrimaryKey != isPrimaryKey {
+			table.updateColumn(c.Name, func(c *Column) { c.IsPrimaryKey = isPrimaryKey })
+		}
+	}
+}
+
+func (table *Table) renameColumnConstraints(oldName, newName string) {
+	replace := func(keys []string) {
+		for i := range keys {
+			if keys[i] == oldName {
+				keys[i] = newName
+			}
+		}
+	}
+	replace(table.PrimaryKey)
+	for _, keys := range table.UniqueKeys {
+		replace(keys)
+	}
+	for _, fk := range table.ForeignKeys {
+		replace(fk.Columns)
+		if fk.RefTable != nil && fk.RefTable.Name == table.Rel.Name {
+			replace(fk.RefColumns)
+		}
+	}
+	for _, index := range table.Indexes {
+		replace(index.Columns)
+	}
 }
 
 func checkMissing(err error, missingOK bool) error {
@@ -91,6 +216,7 @@ func (c *Catalog) dropColumn(table *Table, cmd *ast.AlterTableCmd) error {
 		}
 	}
 	table.Columns = append(table.Columns[:index], table.Columns[index+1:]...)
+	table.dropColumnConstraints(col.Name)
 	return nil
 }
 
@@ -129,6 +255,11 @@ type Column struct {
 	Comment    string
 	Length     *int
 
+	IsPrimaryKey bool
+	IsUnique     bool
+	HasDefault   bool
+	Default      string
+
 	linkedType bool
 }
 
@@ -177,6 +308,8 @@ func isStmtImplemented(stmt *ast.AlterTableStmt) bool {
 				implemented = true
 			case ast.AT_SetNotNull:
 				implemented = true
+			case ast.AT_AddConstraint:
+				implemented = true
 			}
 		}
 	}
@@ -215,6 +348,10 @@ func (c *Catalog) alterTable(stmt *ast.AlterTableStmt) error {
 				if err := table.setNotNull(cmd); err != nil {
 					return err
 				}
+			case ast.AT_AddConstraint:
+				if err := c.addConstraint(table, cmd.Constraint); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -285,6 +422,12 @@ func (c *Catalog) createTable(stmt *ast.CreateTableStmt) error {
 
 			seen[col.Name] = col.IsNotNull
 			coltype[col.Name] = col.Type
+			if col.IsPrimaryKey || col.IsUnique {
+				// key constraints are not inherited
+				inherited := *col
+				inherited.IsPrimaryKey, inherited.IsUnique = false, false
+				col = &inherited
+			}
 			tbl.Columns = append(tbl.Columns, col)
 		}
 	}
@@ -324,6 +467,17 @@ func (c *Catalog) createTable(stmt *ast.CreateTableStmt) error {
 		}
 	}
 
+	// Primary keys are applied first, so self references can be resolved
+	constraints := slices.Clone(stmt.Constraints)
+	slices.SortStableFunc(constraints, func(a, b *ast.TableConstraint) int {
+		return cmp.Compare(a.Contype, b.Contype)
+	})
+	for _, con := range constraints {
+		if err := c.addConstraint(&tbl, con); err != nil {
+			return err
+		}
+	}
+
 	schema.Tables = append(schema.Tables, &tbl)
 	return nil
 }
@@ -338,6 +492,8 @@ func (c *Catalog) defineColumn(table *ast.TableName, col *ast.ColumnDef) (*Colum
 		ArrayDims:  col.ArrayDims,
 		Comment:    col.Comment,
 		Length:     col.Length,
+		HasDefault: col.HasDefault,
+		Default:    col.Default,
 	}
 	if col.Vals != nil {
 		typeName := ast.TypeName{
@@ -407,6 +563,7 @@ func (c *Catalog) renameColumn(stmt *ast.RenameColumnStmt) error {
 		return sqlerr.ColumnNotFound(tbl.Rel.Name, stmt.Col.Name)
 	}
 	tbl.Columns[idx].Name = *stmt.NewName
+	tbl.renameColumnConstraints(stmt.Col.Name, *stmt.NewName)
 
 	if tbl.Columns[idx].linkedType {
 		name := fmt.Sprintf("%s_%s", tbl.Rel.Name, *stmt.NewName)
@@ -432,6 +589,21 @@ func (c *Catalog) renameTable(stmt *ast.RenameTableStmt) error {
 	}
 	if stmt.NewName != nil {
 		tbl.Rel.Name = *stmt.NewName
+
+		// keep foreign keys pointing to the renamed table
+		for _, schema := range c.Schemas {
+			for _, t := range schema.Tables {
+				for _, fk := range t.ForeignKeys {
+					if fk.RefTable != nil && fk.RefTable.Name == stmt.Table.Name && fk.RefTable.Schema == stmt.Table.Schema {
+						fk.RefTable = &ast.TableName{
+							Catalog: fk.RefTable.Catalog,
+							Schema:  fk.RefTable.Schema,
+							Name:    *stmt.NewName,
+						}
+					}
+				}
+			}
+		}
 	}
 
 	for idx := range tbl.Columns {
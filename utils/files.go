@@ -80,3 +80,35 @@ func RemoveFiles(dir, nameSuffix string) error {
 func RemoveFile(filePath string) error {
 	return os.Remove(filePath)
 }
+
+// RemoveGeneratedFiles - remove all files in dir with name suffix which contain the marker line
+func RemoveGeneratedFiles(dir, nameSuffix, marker string) error {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	dirItems, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range dirItems {
+		if item.IsDir() || !strings.HasSuffix(item.Name(), nameSuffix) {
+			continue
+		}
+
+		filePath := filepath.Join(dir, item.Name())
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(string(data), marker) {
+			if err := os.Remove(filePath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}